@@ -1,11 +1,21 @@
 package hh_lol_prophet
 
 import (
-	"encoding/json"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
 
+	"github.com/real-web-world/bdk/fastcurd"
 	ginApp "github.com/real-web-world/bdk/gin"
 
 	"github.com/real-web-world/hh-lol-prophet/conf"
@@ -15,6 +25,21 @@ import (
 	lcuModels "github.com/real-web-world/hh-lol-prophet/services/lcu/models"
 )
 
+const (
+	batchQueryHorseMaxCount = 10
+	localApiTokenHeader     = "X-Api-Token"
+	localApiTokenQuery      = "apiToken"
+)
+
+var (
+	eventWsUpgrader = websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || isAllowOrigin(origin)
+		},
+	}
+)
+
 type (
 	Api struct {
 		p *Prophet
@@ -22,16 +47,97 @@ type (
 	summonerNameReq struct {
 		SummonerName string `json:"summonerName"`
 	}
+	queryHorseResp struct {
+		Score   float64  `json:"score"`
+		CurrKDA [][3]int `json:"currKDA"`
+		Horse   string   `json:"horse"`
+	}
+	lcuAuthInfoResp struct {
+		Token string `json:"token"`
+		Port  int    `json:"port"`
+	}
+	autoAcceptPauseResp struct {
+		Paused bool `json:"paused"`
+	}
+	previewMsgTplResp struct {
+		Msg string `json:"msg"`
+	}
+	riotIDListReq struct {
+		RiotIDList []string `json:"riotIDList"`
+	}
+	batchQueryHorseItem struct {
+		RiotID     string   `json:"riotID"`
+		SummonerID int64    `json:"summonerID"`
+		Score      float64  `json:"score"`
+		CurrKDA    [][3]int `json:"currKDA"`
+		Horse      string   `json:"horse"`
+		Error      string   `json:"error"` // 为空表示查询成功
+	}
+	saveChampionRuneReq struct {
+		ChampionID      int    `json:"championID"`
+		Position        string `json:"position"`
+		PrimaryStyleID  int    `json:"primaryStyleID"`
+		SubStyleID      int    `json:"subStyleID"`
+		SelectedPerkIDs []int  `json:"selectedPerkIDs"`
+		Spell1ID        int    `json:"spell1ID"`
+		Spell2ID        int    `json:"spell2ID"`
+	}
+	idReq struct {
+		ID int64 `json:"id"`
+	}
+	setAutoAcceptPauseReq struct {
+		Paused bool `json:"paused"`
+	}
+	previewMsgTplReq struct {
+		Type string `json:"type"`
+		Tpl  string `json:"tpl"`
+	}
+	profileNameReq struct {
+		Name string `json:"name"`
+	}
+	saveProfileReq struct {
+		Name        string               `json:"name"`
+		QueueIDList []int                `json:"queueIDList"`
+		Conf        *conf.ClientUserConf `json:"conf"` // 为空时使用当前配置
+	}
+	profileListResp struct {
+		Active string               `json:"active"` // 当前使用的方案 为空表示未使用
+		List   []conf.ClientProfile `json:"list"`
+	}
 )
 
 func (api Api) ProphetActiveMid(c *gin.Context) {
 	app := ginApp.GetApp(c)
-	if !api.p.lcuActive {
+	if !api.p.isLcuActive() {
 		app.ErrorMsg("请检查lol客户端是否已启动")
 		return
 	}
 	c.Next()
 }
+
+// 开启本地api token后 需在header或query中携带token
+func (api Api) LocalApiTokenMid(c *gin.Context) {
+	if !global.GetClientUserConf().LocalApiTokenEnabled {
+		c.Next()
+		return
+	}
+	token := c.GetHeader(localApiTokenHeader)
+	if token == "" {
+		token = c.Query(localApiTokenQuery)
+	}
+	expectToken := global.GetLocalApiToken()
+	if expectToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expectToken)) != 1 {
+		ginApp.GetApp(c).NoAuth()
+		return
+	}
+	// 避免转发给lcu
+	c.Request.Header.Del(localApiTokenHeader)
+	if query := c.Request.URL.Query(); query.Has(localApiTokenQuery) {
+		query.Del(localApiTokenQuery)
+		c.Request.URL.RawQuery = query.Encode()
+	}
+	c.Next()
+}
 func (api Api) QueryHorseBySummonerName(c *gin.Context) {
 	app := ginApp.GetApp(c)
 	d := &summonerNameReq{}
@@ -40,42 +146,84 @@ func (api Api) QueryHorseBySummonerName(c *gin.Context) {
 		return
 	}
 	summonerName := strings.TrimSpace(d.SummonerName)
+	cli := api.p.getLcuClient()
 	var summoner *lcuModels.Summoner
 	if summonerName == "" {
-		if api.p.currSummoner == nil {
+		currSummoner := api.p.getCurrSummoner()
+		if currSummoner == nil {
 			app.ErrorMsg("系统错误")
 			return
 		}
-		summoner = lcu.ConvertCurrSummonerToSummoner(api.p.currSummoner)
+		summoner = lcu.ConvertCurrSummonerToSummoner(currSummoner)
 	} else {
-		info, err := lcu.QuerySummonerByName(summonerName)
+		info, err := querySummonerByName(c.Request.Context(), cli, summonerName)
 		if err != nil || info.SummonerId <= 0 {
 			app.ErrorMsg("未查询到召唤师")
 			return
 		}
 		summoner = info
 	}
-	scoreInfo, err := GetUserScore(summoner)
+	scoreInfo, err := GetUserScore(c.Request.Context(), cli, summoner)
 	if err != nil {
 		app.CommonError(err)
 		return
 	}
 	scoreCfg := global.GetScoreConf()
 	clientUserCfg := global.GetClientUserConf()
-	var horse string
-	for i, v := range scoreCfg.Horse {
-		if scoreInfo.Score >= v.Score {
-			horse = clientUserCfg.HorseNameConf[i]
-			break
-		}
-	}
-	app.Data(gin.H{
-		"score":   scoreInfo.Score,
-		"currKDA": scoreInfo.CurrKDA,
-		"horse":   horse,
+	horse, _ := getHorseByScore(scoreInfo.Score, scoreCfg, clientUserCfg)
+	app.Data(queryHorseResp{
+		Score:   scoreInfo.Score,
+		CurrKDA: scoreInfo.CurrKDA,
+		Horse:   horse,
 	})
 }
 
+// 批量查询马匹信息 单个失败不影响其他
+func (api Api) BatchQueryHorse(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	d := &riotIDListReq{}
+	if err := c.ShouldBind(d); err != nil {
+		app.ValidError(err)
+		return
+	}
+	if len(d.RiotIDList) == 0 || len(d.RiotIDList) > batchQueryHorseMaxCount {
+		app.ErrorMsg(fmt.Sprintf("一次最多查询%d个召唤师", batchQueryHorseMaxCount))
+		return
+	}
+	scoreCfg := global.GetScoreConf()
+	clientUserCfg := global.GetClientUserConf()
+	list := make([]batchQueryHorseItem, len(d.RiotIDList))
+	cli := api.p.getLcuClient()
+	g := errgroup.Group{}
+	for i, riotID := range d.RiotIDList {
+		item := &list[i]
+		item.RiotID = strings.TrimSpace(riotID)
+		g.Go(func() error {
+			gameName, tagLine, ok := parseRiotID(item.RiotID)
+			if !ok {
+				item.Error = "riot id格式错误,应为 gameName#tagLine"
+				return nil
+			}
+			summoner, err := cli.QuerySummonerByRiotID(c.Request.Context(), gameName, tagLine)
+			if err != nil || summoner.SummonerId <= 0 {
+				item.Error = "未查询到召唤师"
+				return nil
+			}
+			scoreInfo, err := GetUserScore(c.Request.Context(), cli, summoner)
+			if err != nil {
+				item.Error = err.Error()
+				return nil
+			}
+			item.SummonerID = scoreInfo.SummonerID
+			item.Score = scoreInfo.Score
+			item.CurrKDA = scoreInfo.CurrKDA
+			item.Horse, _ = getHorseByScore(scoreInfo.Score, scoreCfg, clientUserCfg)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	app.Data(list)
+}
 func (api Api) CopyHorseMsgToClipBoard(c *gin.Context) {
 	app := ginApp.GetApp(c)
 	app.Success()
@@ -84,6 +232,17 @@ func (api Api) GetAllConf(c *gin.Context) {
 	app := ginApp.GetApp(c)
 	app.Data(global.GetClientUserConf())
 }
+
+// 配置项说明 前端按此渲染设置界面
+func (api Api) GetConfSchema(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	schema, err := conf.GenClientConfSchema(global.DefaultClientUserConf)
+	if err != nil {
+		app.CommonError(err)
+		return
+	}
+	app.Data(schema)
+}
 func (api Api) UpdateClientConf(c *gin.Context) {
 	app := ginApp.GetApp(c)
 	d := &conf.UpdateClientUserConfReq{}
@@ -91,14 +250,18 @@ func (api Api) UpdateClientConf(c *gin.Context) {
 		app.ValidError(err)
 		return
 	}
-	cfg := global.SetClientUserConf(*d)
-	bts, _ := json.Marshal(cfg)
-	m := models.Config{}
-	err := m.Update(models.LocalClientConfKey, string(bts))
+	tokenEnabled := global.GetClientUserConf().LocalApiTokenEnabled
+	cfg, err := api.p.updateClientConf(c.Request.Context(), *d)
 	if err != nil {
-		app.CommonError(err)
+		sendClientConfErr(app, err)
 		return
 	}
+	if !tokenEnabled && cfg.LocalApiTokenEnabled {
+		log.Println("已开启本地api token,请在界面中填写: " + global.GetLocalApiToken())
+	}
+	if d.OfflineMode != nil && *d.OfflineMode != global.IsOfflineMode() {
+		log.Println("离线模式设置已保存,重启后生效")
+	}
 	app.Success()
 }
 func (api Api) DevHand(c *gin.Context) {
@@ -118,9 +281,9 @@ func (api Api) GetLcuAuthInfo(c *gin.Context) {
 		app.CommonError(err)
 		return
 	}
-	app.Data(gin.H{
-		"token": token,
-		"port":  port,
+	app.Data(lcuAuthInfoResp{
+		Token: token,
+		Port:  port,
 	})
 }
 func (api Api) LcuProxy(c *gin.Context) {
@@ -134,3 +297,303 @@ func (api Api) LcuProxy(c *gin.Context) {
 	}
 	rp.ServeHTTP(c.Writer, c.Request)
 }
+
+// 以sse推送事件
+func (api Api) SubscribeEvents(c *gin.Context) {
+	events, unsubscribe := api.p.subscribeEvents()
+	defer unsubscribe()
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			_, err := w.Write([]byte(": ping\n\n"))
+			return err == nil
+		case evt, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(evt.Type), evt)
+			return true
+		}
+	})
+}
+
+// 以websocket推送事件
+func (api Api) SubscribeEventsWs(c *gin.Context) {
+	conn, err := eventWsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	events, unsubscribe := api.p.subscribeEvents()
+	defer unsubscribe()
+	// 客户端不会发送消息 读取仅用于感知连接关闭
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+				return
+			}
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			if err = conn.WriteJSON(evt); err != nil {
+				return
+			}
+		}
+	}
+}
+func (api Api) ListChampionRune(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	list, err := models.ChampionRune{Ctx: c}.List()
+	if err != nil {
+		app.CommonError(err)
+		return
+	}
+	app.Data(list)
+}
+func (api Api) SaveChampionRune(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	d := &saveChampionRuneReq{}
+	if err := c.ShouldBind(d); err != nil {
+		app.ValidError(err)
+		return
+	}
+	item := &models.ChampionRune{
+		ChampionID:      d.ChampionID,
+		Position:        d.Position,
+		PrimaryStyleID:  d.PrimaryStyleID,
+		SubStyleID:      d.SubStyleID,
+		SelectedPerkIDs: d.SelectedPerkIDs,
+		Spell1ID:        d.Spell1ID,
+		Spell2ID:        d.Spell2ID,
+	}
+	if err := checkChampionRune(item); err != nil {
+		app.ErrorMsg(err.Error())
+		return
+	}
+	if err := (models.ChampionRune{Ctx: c}).Save(item); err != nil {
+		app.CommonError(err)
+		return
+	}
+	app.Data(item)
+}
+func (api Api) DelChampionRune(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	d := &idReq{}
+	if err := c.ShouldBind(d); err != nil {
+		app.ValidError(err)
+		return
+	}
+	if err := (models.ChampionRune{Ctx: c}).Delete(d.ID); err != nil {
+		app.CommonError(err)
+		return
+	}
+	app.Success()
+}
+
+func (api Api) GetCurrentMatch(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	app.Data(api.p.getCurrentMatch())
+}
+func (api Api) RefreshCurrentMatch(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	match, err := api.p.refreshCurrentMatch(c.Request.Context())
+	if err != nil {
+		app.CommonError(err)
+		return
+	}
+	app.Data(match)
+}
+
+// 优先返回已保存的报告 未保存时客户端在线则实时生成
+func (api Api) GetGameReport(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	gameID, err := strconv.ParseInt(c.Param("gameId"), 10, 64)
+	if err != nil || gameID <= 0 {
+		app.ErrorMsg("对局id错误")
+		return
+	}
+	item, err := (models.GameReport{Ctx: c}).FindByGameID(gameID)
+	if err != nil {
+		app.CommonError(err)
+		return
+	}
+	if item != nil {
+		app.Data(item.Data)
+		return
+	}
+	if !api.p.isLcuActive() {
+		app.ErrorMsg("未找到赛后报告,请检查lol客户端是否已启动")
+		return
+	}
+	// 按需生成的报告不附带赛前得分 当前记录的赛前得分可能属于其他对局
+	report, err := api.p.buildGameReport(c.Request.Context(), gameID, false)
+	if err != nil {
+		app.CommonError(err)
+		return
+	}
+	item = &models.GameReport{Ctx: c, GameID: gameID, Data: *report}
+	if err = (models.GameReport{Ctx: c}).Save(item); err != nil {
+		app.CommonError(err)
+		return
+	}
+	app.Data(report)
+}
+func (api Api) GetAutoAcceptPause(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	app.Data(autoAcceptPauseResp{
+		Paused: api.p.isAutoAcceptPaused(),
+	})
+}
+func (api Api) SetAutoAcceptPause(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	d := &setAutoAcceptPauseReq{}
+	if err := c.ShouldBind(d); err != nil {
+		app.ValidError(err)
+		return
+	}
+	api.p.setAutoAcceptPaused(d.Paused)
+	app.Data(autoAcceptPauseResp{
+		Paused: d.Paused,
+	})
+}
+func (api Api) PreviewMsgTpl(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	d := &previewMsgTplReq{}
+	if err := c.ShouldBind(d); err != nil {
+		app.ValidError(err)
+		return
+	}
+	msg, err := previewMsgTpl(d.Type, d.Tpl)
+	if err != nil {
+		app.CommonError(err)
+		return
+	}
+	app.Data(previewMsgTplResp{
+		Msg: msg,
+	})
+}
+
+func (api Api) ListProfile(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	list, err := listClientProfiles()
+	if err != nil {
+		app.CommonError(err)
+		return
+	}
+	app.Data(profileListResp{
+		Active: getActiveProfileName(),
+		List:   list,
+	})
+}
+
+// 新建或覆盖方案
+func (api Api) SaveProfile(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	d := &saveProfileReq{}
+	if err := c.ShouldBind(d); err != nil {
+		app.ValidError(err)
+		return
+	}
+	profile := conf.ClientProfile{
+		Name:        strings.TrimSpace(d.Name),
+		QueueIDList: d.QueueIDList,
+		Conf:        global.GetClientUserConf(),
+	}
+	if profile.QueueIDList == nil {
+		profile.QueueIDList = []int{}
+	}
+	if d.Conf != nil {
+		profile.Conf = *d.Conf
+	}
+	if err := saveClientProfile(profile); err != nil {
+		sendClientConfErr(app, err)
+		return
+	}
+	app.Data(profile)
+}
+func (api Api) SwitchProfile(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	d := &profileNameReq{}
+	if err := c.ShouldBind(d); err != nil {
+		app.ValidError(err)
+		return
+	}
+	cfg, err := api.p.switchClientProfile(d.Name)
+	if err != nil {
+		app.CommonError(err)
+		return
+	}
+	app.Data(cfg)
+}
+func (api Api) DelProfile(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	d := &profileNameReq{}
+	if err := c.ShouldBind(d); err != nil {
+		app.ValidError(err)
+		return
+	}
+	if err := deleteClientProfile(d.Name); err != nil {
+		app.CommonError(err)
+		return
+	}
+	app.Success()
+}
+
+// 导出客户端配置 方案 符文及计分配置
+func (api Api) ExportConf(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	doc, err := api.p.exportConfigDoc()
+	if err != nil {
+		app.CommonError(err)
+		return
+	}
+	app.Data(doc)
+}
+
+// 导入export导出的文档 兼容旧版本
+func (api Api) ImportConf(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	bts, err := c.GetRawData()
+	if err != nil {
+		app.ValidError(err)
+		return
+	}
+	if err = api.p.importConfigDoc(bts); err != nil {
+		app.ErrorMsg(err.Error())
+		return
+	}
+	app.Success()
+}
+
+// 配置校验失败时data为各字段的错误
+func sendClientConfErr(app *ginApp.App, err error) {
+	var validErr conf.ClientConfValidErr
+	if errors.As(err, &validErr) {
+		app.JSON(&fastcurd.RetJSON{Code: fastcurd.CodeValidError, Msg: validErr.Error(), Data: validErr})
+		return
+	}
+	app.CommonError(err)
+}
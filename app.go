@@ -1,6 +1,7 @@
 package hh_lol_prophet
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/avast/retry-go"
 	"github.com/pkg/errors"
 	"github.com/real-web-world/bdk"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 
@@ -17,6 +19,7 @@ import (
 	"github.com/real-web-world/hh-lol-prophet/services/lcu"
 	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
 	"github.com/real-web-world/hh-lol-prophet/services/logger"
+	"github.com/real-web-world/hh-lol-prophet/services/metrics"
 )
 
 const (
@@ -24,24 +27,31 @@ const (
 	minGameDurationSec = 15 * 60
 )
 
-var (
-	//SendConversationMsg   = lcu.SendConversationMsg
-	ListConversationMsg   = lcu.ListConversationMsg
-	GetCurrConversationID = lcu.GetCurrConversationID
-	QuerySummoner         = lcu.QuerySummoner
-	QueryGameSummary      = lcu.QueryGameSummary
-)
-
-func SendConversationMsg(msg, conversationID string) error {
+func SendConversationMsg(ctx context.Context, cli lcu.Api, msg, conversationID string) error {
 	scoreCfg := global.GetScoreConf()
 	for oriStr, replaceStr := range scoreCfg.StrReplaceMap {
 		msg = strings.Replace(msg, oriStr, replaceStr, -1)
 	}
 	log.Println(40, msg)
-	return lcu.SendConversationMsg(msg, conversationID)
+	return cli.SendConversationMsg(ctx, msg, conversationID)
+}
+
+// 等待d或ctx取消 ctx取消时返回false
+func sleepWithCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
 }
-func listSummoner(summonerIDList []int64) (map[int64]*models.Summoner, error) {
-	list, err := lcu.ListSummoner(summonerIDList)
+func listSummoner(ctx context.Context, cli lcu.Api, summonerIDList []int64) (map[int64]*models.Summoner, error) {
+	_, span := tracer.Start(ctx, "listSummoner",
+		trace.WithAttributes(traceAttrSummonerCount.Int(len(summonerIDList))))
+	list, err := cli.ListSummoner(ctx, summonerIDList)
+	endSpan(span, err)
 	if err != nil {
 		return nil, err
 	}
@@ -52,16 +62,39 @@ func listSummoner(summonerIDList []int64) (map[int64]*models.Summoner, error) {
 	}
 	return res, nil
 }
-func getTeamUsers() (string, []int64, error) {
-	conversationID, err := GetCurrConversationID()
+
+// 解析 gameName#tagLine
+func parseRiotID(riotID string) (gameName, tagLine string, ok bool) {
+	idx := strings.LastIndex(riotID, "#")
+	if idx <= 0 || idx == len(riotID)-1 {
+		return "", "", false
+	}
+	return strings.TrimSpace(riotID[:idx]), strings.TrimSpace(riotID[idx+1:]), true
+}
+
+// 包含#时按riot id查询 否则按召唤师名称查询
+func querySummonerByName(ctx context.Context, cli lcu.Api, name string) (*models.Summoner, error) {
+	if gameName, tagLine, ok := parseRiotID(name); ok {
+		return cli.QuerySummonerByRiotID(ctx, gameName, tagLine)
+	}
+	return cli.QuerySummonerByName(ctx, name)
+}
+func getTeamUsers(ctx context.Context, cli lcu.Api) (conversationID string, summonerIDList []int64, err error) {
+	_, span := tracer.Start(ctx, "getTeamUsers")
+	defer func() {
+		span.SetAttributes(traceAttrConversationID.String(conversationID),
+			traceAttrSummonerCount.Int(len(summonerIDList)))
+		endSpan(span, err)
+	}()
+	conversationID, err = cli.GetCurrConversationID(ctx)
 	if err != nil {
 		return "", nil, err
 	}
-	msgList, err := ListConversationMsg(conversationID)
+	msgList, err := cli.ListConversationMsg(ctx, conversationID)
 	if err != nil {
 		return "", nil, err
 	}
-	summonerIDList := getSummonerIDListFromConversationMsgList(msgList)
+	summonerIDList = getSummonerIDListFromConversationMsgList(msgList)
 	return conversationID, summonerIDList, nil
 }
 func getSummonerIDListFromConversationMsgList(msgList []models.ConversationMsg) []int64 {
@@ -73,15 +106,20 @@ func getSummonerIDListFromConversationMsgList(msgList []models.ConversationMsg)
 	}
 	return summonerIDList
 }
-func GetUserScore(summoner *models.Summoner) (*lcu.UserScore, error) {
+func GetUserScore(ctx context.Context, cli lcu.Api, summoner *models.Summoner) (*lcu.UserScore, error) {
+	defer func(start time.Time) {
+		metrics.ObservePlayerScore(time.Since(start))
+	}(time.Now())
 	summonerID := summoner.SummonerId
+	ctx, span := tracer.Start(ctx, "GetUserScore", trace.WithAttributes(traceAttrSummonerID.Int64(summonerID)))
+	defer span.End()
 	userScoreInfo := &lcu.UserScore{
 		SummonerID: summonerID,
 		Score:      defaultScore,
 	}
 	userScoreInfo.SummonerName = fmt.Sprintf("%s#%s", summoner.GameName, summoner.TagLine)
 	// 获取战绩列表
-	gameList, err := listGameHistory(summoner.Puuid)
+	gameList, err := listGameHistory(ctx, cli, summoner.Puuid)
 	if err != nil {
 		logger.Error("获取用户战绩失败", zap.Error(err), zap.Int64("id", summonerID))
 		return userScoreInfo, nil
@@ -100,11 +138,14 @@ func GetUserScore(summoner *models.Summoner) (*lcu.UserScore, error) {
 		}
 		g.Go(func() error {
 			var gameSummary *models.GameSummary
-			err = retry.Do(func() error {
+			gameCtx, gameSpan := tracer.Start(ctx, "QueryGameSummary",
+				trace.WithAttributes(traceAttrGameID.Int64(info.GameId)))
+			err := retry.Do(func() error {
 				var tmpErr error
-				gameSummary, tmpErr = QueryGameSummary(info.GameId)
+				gameSummary, tmpErr = cli.QueryGameSummary(gameCtx, info.GameId)
 				return tmpErr
-			}, retry.Delay(time.Millisecond*10), retry.Attempts(5))
+			}, retry.Delay(time.Millisecond*10), retry.Attempts(5), retry.Context(gameCtx))
+			endSpan(gameSpan, err)
 			if err != nil {
 				logger.Debug("获取游戏对局详细信息失败", zap.Error(err), zap.Int64("id", info.GameId))
 				return nil
@@ -198,14 +239,17 @@ func GetUserScore(summoner *models.Summoner) (*lcu.UserScore, error) {
 		weightTotalScore = defaultScore
 	}
 	userScoreInfo.Score = weightTotalScore
+	span.SetAttributes(traceAttrGameCount.Int(len(gameSummaryList)))
 	return userScoreInfo, nil
 }
 
-func listGameHistory(puuid string) ([]models.GameInfo, error) {
+func listGameHistory(ctx context.Context, cli lcu.Api, puuid string) ([]models.GameInfo, error) {
 	scoreCfg := global.GetScoreConf()
 	limit := 20
 	fmtList := make([]models.GameInfo, 0, limit)
-	resp, err := lcu.ListGamesByPUUID(puuid, 0, limit)
+	_, span := tracer.Start(ctx, "ListGamesByPUUID")
+	resp, err := cli.ListGamesByPUUID(ctx, puuid, 0, limit)
+	endSpan(span, err)
 	if err != nil {
 		logger.Error("查询用户战绩失败", zap.Error(err), zap.String("puuid", puuid))
 		return nil, err
@@ -572,3 +616,12 @@ func getAllUsersFromSession(selfID int64, session *models.GameFlowSession) (self
 	}
 	return
 }
+func getChampionIDMapFromSession(session *models.GameFlowSession) map[int64]int {
+	res := make(map[int64]int, 10)
+	for _, teamUser := range append(session.GameData.TeamOne, session.GameData.TeamTwo...) {
+		if championID, ok := teamUser.ChampionId.(float64); ok {
+			res[int64(teamUser.SummonerId)] = int(championID)
+		}
+	}
+	return res
+}
@@ -0,0 +1,111 @@
+package hh_lol_prophet
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+	"github.com/real-web-world/hh-lol-prophet/services/logger"
+)
+
+// 英雄在优先级列表中的排名 越小越优先 不在列表中时排在最后
+func aramChampRank(priority []int, championID int) int {
+	if idx := slices.Index(priority, championID); idx >= 0 {
+		return idx
+	}
+	return len(priority)
+}
+
+// 返回备选席中比当前英雄更优先的英雄 没有时返回0
+func pickAramBenchChampion(priority []int, currChampionID int, benchChampionIDs []int) int {
+	bestChampionID := 0
+	bestRank := aramChampRank(priority, currChampionID)
+	for _, championID := range benchChampionIDs {
+		if rank := aramChampRank(priority, championID); rank < bestRank {
+			bestChampionID = championID
+			bestRank = rank
+		}
+	}
+	return bestChampionID
+}
+
+// 配置的延迟再加上最多一半的随机抖动
+func aramSwapDelay(delayMs int) time.Duration {
+	if delayMs <= 0 {
+		return 0
+	}
+	return time.Duration(delayMs+rand.IntN(delayMs/2+1)) * time.Millisecond
+}
+
+// 返回应交换的备选席英雄 没有可交换的英雄时判断是否需要重随
+func decideAramAction(sessionInfo *models.ChampSelectSessionInfo,
+	clientCfg conf.ClientUserConf) (swapChampionID int, reroll bool) {
+	currChampionID := getLocalPlayerChampionID(sessionInfo)
+	if currChampionID == 0 {
+		return 0, false
+	}
+	benchChampionIDs := make([]int, 0, len(sessionInfo.BenchChampions))
+	for _, item := range sessionInfo.BenchChampions {
+		benchChampionIDs = append(benchChampionIDs, item.ChampionId)
+	}
+	priority := clientCfg.AramChampPriority
+	if championID := pickAramBenchChampion(priority, currChampionID, benchChampionIDs); championID > 0 {
+		return championID, false
+	}
+	isAcceptable := slices.Contains(priority, currChampionID)
+	return 0, !isAcceptable && clientCfg.AramAutoReroll && sessionInfo.AllowRerolling &&
+		sessionInfo.RerollsRemaining > 0
+}
+
+func (p *Prophet) onAramBenchUpdate(ctx context.Context, sessionInfo *models.ChampSelectSessionInfo,
+	clientCfg conf.ClientUserConf) {
+	if !sessionInfo.BenchEnabled || len(clientCfg.AramChampPriority) == 0 {
+		return
+	}
+	if championID, reroll := decideAramAction(sessionInfo, clientCfg); championID == 0 && !reroll {
+		return
+	}
+	p.mu.Lock()
+	if p.aramSwapping {
+		p.mu.Unlock()
+		return
+	}
+	p.aramSwapping = true
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.aramSwapping = false
+		p.mu.Unlock()
+	}()
+	if !sleepWithCtx(ctx, aramSwapDelay(clientCfg.AramSwapDelayMs)) {
+		return
+	}
+	// 等待期间的备选席更新被忽略 重新获取后再决定
+	cli := p.getLcuClient()
+	latest, err := cli.GetChampSelectSession(ctx)
+	if err != nil {
+		logger.Debug("获取选人会话失败", zap.Error(err))
+		return
+	}
+	championID, reroll := decideAramAction(latest, clientCfg)
+	switch {
+	case championID > 0:
+		if err = cli.SwapBenchChampion(ctx, championID); err != nil {
+			logger.Debug("大乱斗交换英雄失败", zap.Error(err), zap.Int("championID", championID))
+		} else {
+			p.emitAutomation(AutomationActionAramSwap, gin.H{"championID": championID})
+		}
+	case reroll:
+		if err = cli.RerollChampion(ctx); err != nil {
+			logger.Debug("大乱斗重随英雄失败", zap.Error(err))
+		} else {
+			p.emitAutomation(AutomationActionAramReroll, nil)
+		}
+	}
+}
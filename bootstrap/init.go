@@ -3,6 +3,7 @@ package bootstrap
 import (
 	"bufio"
 	"context"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
@@ -20,15 +21,20 @@ import (
 	"github.com/real-web-world/bdk"
 	"go.opentelemetry.io/contrib/bridges/otelzap"
 	"go.opentelemetry.io/contrib/processors/minsev"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	otelLogGlobal "go.opentelemetry.io/otel/log/global"
 	otelLog "go.opentelemetry.io/otel/sdk/log"
 	"go.opentelemetry.io/otel/sdk/resource"
+	otelTrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"golang.org/x/sync/errgroup"
+	"gopkg.in/natefinch/lumberjack.v2"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
@@ -43,12 +49,13 @@ import (
 
 const (
 	DefaultTZ         = "Asia/Shanghai"
-	EnvFileName       = ".env"
-	EnvLocalFileName  = ".env.local"
-	LocalConfFilePath = "./config.json"
+	EnvFileName       = conf.EnvFileName
+	EnvLocalFileName  = conf.EnvLocalFileName
+	LocalConfFilePath = conf.LocalConfFilePath
 )
 
-func getRemoteConf() (*conf.AppConf, error) {
+// 返回已校验签名的远程配置data原文
+func getRemoteConf() ([]byte, error) {
 	cli := http.Client{
 		Timeout: time.Second * 2,
 	}
@@ -63,57 +70,75 @@ func getRemoteConf() (*conf.AppConf, error) {
 	type BuffResp struct {
 		Code int             `json:"code"`
 		Data json.RawMessage `json:"data"`
+		Sign string          `json:"sign"` // data原文的ed25519签名
 	}
 	res := &BuffResp{}
 	if err = json.Unmarshal(bts, res); err != nil {
 		return nil, err
 	}
-	cfg := &conf.AppConf{}
-	if err = json.Unmarshal(res.Data, cfg); err != nil {
-		return nil, err
-	}
-	if cfg.AppName == "" {
+	if res.Code != 0 || len(res.Data) == 0 {
 		return nil, errors.New("获取远程配置失败")
 	}
-	return cfg, nil
+	if !conf.RemoteConfSignEnabled() {
+		return res.Data, nil
+	}
+	if err = conf.VerifyRemoteConfSign(res.Data, res.Sign); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
 }
 func initConf() {
 	_ = godotenv.Load(EnvFileName)
 	if bdk.IsFile(EnvLocalFileName) {
 		_ = godotenv.Overload(EnvLocalFileName)
 	}
-	remoteConfC := make(chan *conf.AppConf, 1)
-	go func() {
-		defer func() {
-			_ = recover()
-		}()
-		if global.IsEnvModeDev() {
-			remoteConfC <- nil
-			return
-		}
-		cfg, _ := getRemoteConf()
-		if cfg != nil {
-			bts, _ := json.Marshal(cfg)
-			_ = os.WriteFile(LocalConfFilePath, bts, 0664)
-		}
-		remoteConfC <- cfg
-	}()
 	*global.Conf = global.DefaultAppConf
 	if err := initClientConf(); err != nil {
 		log.Fatalf("本地配置错误,请删除%s文件后重启,错误信息:%v", conf.SqliteDBPath, err)
 	}
-	remoteConf := <-remoteConfC
-	if remoteConf == nil {
-		localConfFiles := make([]string, 0, 1)
-		if bdk.IsFile(LocalConfFilePath) {
-			localConfFiles = append(localConfFiles, LocalConfFilePath)
-		}
-		if err := configor.Load(global.Conf, localConfFiles...); err != nil {
-			log.Fatalf("本地配置错误:%v", err)
-		}
-	} else {
-		global.Conf = remoteConf
+	// 启动参数 环境变量及客户端配置任一开启即为离线模式
+	offline := global.IsOfflineMode() || global.IsEnvOffline() || global.GetClientUserConf().OfflineMode
+	global.SetOfflineMode(offline)
+	if offline {
+		log.Println("已开启离线模式,不请求远程配置 不上报日志 不检查更新")
+	}
+	// 默认配置 < 本地配置文件 < 环境变量
+	localConfFiles := make([]string, 0, 1)
+	if bdk.IsFile(LocalConfFilePath) {
+		localConfFiles = append(localConfFiles, LocalConfFilePath)
+	}
+	if err := configor.Load(global.Conf, localConfFiles...); err != nil {
+		log.Fatalf("本地配置错误:%v", err)
+	}
+	if global.IsEnvModeDev() || offline {
+		return
+	}
+	if !conf.RemoteConfEnabled() {
+		log.Println("未配置远程配置签名公钥,使用本地配置")
+		return
+	}
+	if !conf.RemoteConfSignEnabled() {
+		log.Println("未配置远程配置签名公钥,构建时已允许使用未签名的远程配置")
+	}
+	if err := applyRemoteConf(); err != nil {
+		log.Println("远程配置不可用,使用本地配置:", err)
+	}
+}
+
+// 远程配置逐字段合并到本地配置上 签名或校验失败时不做任何修改
+func applyRemoteConf() error {
+	data, err := getRemoteConf()
+	if err != nil {
+		return err
+	}
+	merged, err := conf.MergeRemoteConf(*global.Conf, data)
+	if err != nil {
+		return err
 	}
+	*global.Conf = merged
+	bts, _ := json.Marshal(merged)
+	_ = os.WriteFile(LocalConfFilePath, bts, 0664)
+	return nil
 }
 
 func initClientConf() (err error) {
@@ -154,11 +179,37 @@ func initClientConf() (err error) {
 		}
 		global.ClientUserConf = localClientConf
 	}
+	if err = models.Migrate(db); err != nil {
+		return
+	}
 	global.SqliteDB = db
+	return initLocalApiToken()
+}
+
+// 首次运行时生成本地api token
+func initLocalApiToken() error {
+	item, err := models.Config{}.Find(models.LocalApiTokenKey)
+	if err != nil {
+		return err
+	}
+	if item != nil {
+		global.SetLocalApiToken(item.Val)
+		return nil
+	}
+	bts := make([]byte, 16)
+	if _, err = rand.Read(bts); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(bts)
+	if err = (models.Config{}).Create(models.LocalApiTokenKey, token); err != nil {
+		return err
+	}
+	global.SetLocalApiToken(token)
 	return nil
 }
 
-func initLog(appName string) {
+// 离线模式下prod只写本地日志文件
+func initLog(appName string, logConf conf.LogConf, offline bool) {
 	ws := zapcore.AddSync(log.Writer())
 	logLevel := zapcore.DebugLevel
 	config := zap.NewProductionEncoderConfig()
@@ -174,9 +225,16 @@ func initLog(appName string) {
 		logWriter := bdk.NewConcurrentWriter(bufWriter)
 		log.SetOutput(logWriter)
 		global.SetCleanup(global.LogWriterCleanupKey, logWriter.Close)
-		core = otelzap.NewCore(appName,
-			otelzap.WithLoggerProvider(otelLogGlobal.GetLoggerProvider()),
-		)
+		if offline {
+			core = zapcore.NewNopCore()
+		} else {
+			core = otelzap.NewCore(appName,
+				otelzap.WithLoggerProvider(otelLogGlobal.GetLoggerProvider()),
+			)
+		}
+	}
+	if !logConf.File.Disabled {
+		core = zapcore.NewTee(core, newLogFileCore(logConf.File, logConf.Level, config))
 	}
 	global.Logger = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(2)).Sugar()
 	if global.IsProdMode() {
@@ -186,16 +244,36 @@ func initLog(appName string) {
 	}
 	return
 }
+
+// 本地json日志 按大小滚动 超出保留天数或个数的历史文件会被删除
+func newLogFileCore(fileConf conf.LogFileConf, level string, encoderConf zapcore.EncoderConfig) zapcore.Core {
+	w := &lumberjack.Logger{
+		Filename:   fileConf.Path,
+		MaxSize:    fileConf.MaxSizeMB,
+		MaxAge:     fileConf.MaxAgeDays,
+		MaxBackups: fileConf.MaxBackups,
+		LocalTime:  true,
+	}
+	global.SetCleanup(global.LogFileCleanupKey, func(_ context.Context) error {
+		return w.Close()
+	})
+	return zapcore.NewCore(
+		zapcore.NewJSONEncoder(encoderConf),
+		zapcore.AddSync(w),
+		zap.NewAtomicLevelAt(conf.LogLevel2Zap(level)),
+	)
+}
 func InitApp() error {
 	admin.MustRunWithAdmin()
 	initConf()
 	initUserInfo()
 	cfg := global.Conf
-	if err := initOtel(context.Background(), cfg.Mode, cfg.AppName, cfg.Log, cfg.Otlp,
-		global.GetUserInfo()); err != nil {
+	if err := initOtel(context.Background(), cfg.Mode, cfg.AppName, cfg.Log, cfg.Otlp, cfg.Trace,
+		global.GetUserInfo(), global.IsOfflineMode()); err != nil {
 		return err
 	}
-	initLog(cfg.AppName)
+	cfg.Log.File = cfg.Log.File.WithDefault()
+	initLog(cfg.AppName, cfg.Log, global.IsOfflineMode())
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	g, ctx := errgroup.WithContext(ctx)
@@ -210,35 +288,13 @@ func InitApp() error {
 		initApi(cfg.BuffApi)
 		return nil
 	})
-	if err := g.Wait(); err != nil {
-		return err
-	}
-	initGlobal()
-	return nil
+	return g.Wait()
 }
 
 func initConsole() {
 	initConsoleAdapt()
 }
 
-func initGlobal() {
-	// 废弃
-	//go initAutoReloadCalcConf()
-}
-
-func initAutoReloadCalcConf() {
-	ticker := time.NewTicker(time.Minute)
-	for {
-		latestScoreConf, err := buffApi.GetClientConf()
-		if err == nil {
-			if latestScoreConf.Enabled {
-				global.SetScoreConf(*latestScoreConf)
-			}
-		}
-		<-ticker.C
-	}
-}
-
 func initApi(buffApiCfg conf.BuffApi) {
 	buffApi.Init(buffApiCfg.Url, buffApiCfg.Timeout)
 }
@@ -254,12 +310,29 @@ func initUserInfo() {
 	global.SetUserMac(hex.EncodeToString(hBts[:]))
 }
 
-func initOtel(ctx context.Context, mode conf.Mode, appName string,
-	logConf conf.LogConf, otlpCfg conf.OtlpConf, userInfo global.UserInfo) error {
+// 离线模式下不创建otlp exporter 仅保留本地文件trace
+func initOtel(ctx context.Context, mode conf.Mode, appName string, logConf conf.LogConf, otlpCfg conf.OtlpConf,
+	traceCfg conf.TraceConf, userInfo global.UserInfo, offline bool) error {
 	res, err := newResource(mode, appName, userInfo)
 	if err != nil {
 		return err
 	}
+	if offline && traceCfg.Exporter == conf.TraceExporterOtlp {
+		traceCfg.Exporter = conf.TraceExporterNone
+	}
+	tracerProvider, err := newTracerProvider(ctx, res, otlpCfg, traceCfg)
+	if err != nil {
+		return err
+	}
+	if tracerProvider != nil {
+		global.SetCleanup(global.OtelTraceCleanupKey, func(c context.Context) error {
+			return tracerProvider.Shutdown(c)
+		})
+		otel.SetTracerProvider(tracerProvider)
+	}
+	if offline {
+		return nil
+	}
 	loggerProvider, err := newLoggerProvider(ctx, res, logConf, otlpCfg)
 	if err != nil {
 		return err
@@ -281,6 +354,46 @@ func newResource(mode conf.Mode, appName string, userInfo global.UserInfo) (*res
 		))
 }
 
+// exporter为none时返回nil 使用默认的noop provider
+func newTracerProvider(ctx context.Context, res *resource.Resource, otlpCfg conf.OtlpConf,
+	traceCfg conf.TraceConf) (*otelTrace.TracerProvider, error) {
+	var exporter otelTrace.SpanExporter
+	switch traceCfg.Exporter {
+	case conf.TraceExporterOtlp:
+		otlpExporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(otlpCfg.EndpointUrl+"/v1/traces"),
+			otlptracehttp.WithHeaders(map[string]string{
+				"Authorization": "Basic " + otlpCfg.Token,
+			}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		exporter = otlpExporter
+	case conf.TraceExporterFile:
+		f, err := os.OpenFile(traceCfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0664)
+		if err != nil {
+			return nil, err
+		}
+		fileExporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		exporter = fileExporter
+	default:
+		return nil, nil
+	}
+	sampleRatio := traceCfg.SampleRatio
+	if sampleRatio <= 0 {
+		sampleRatio = 1
+	}
+	return otelTrace.NewTracerProvider(
+		otelTrace.WithResource(res),
+		otelTrace.WithBatcher(exporter),
+		otelTrace.WithSampler(otelTrace.ParentBased(otelTrace.TraceIDRatioBased(sampleRatio))),
+	), nil
+}
+
 func newLoggerProvider(ctx context.Context, res *resource.Resource,
 	logConf conf.LogConf, otlpCfg conf.OtlpConf) (*otelLog.LoggerProvider, error) {
 	exporter, err := otlploghttp.New(ctx, otlploghttp.WithEndpointURL(otlpCfg.EndpointUrl+"/v1/logs"),
@@ -0,0 +1,183 @@
+package hh_lol_prophet
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/avast/retry-go"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"github.com/real-web-world/hh-lol-prophet/services/lcu"
+	"github.com/real-web-world/hh-lol-prophet/services/logger"
+	"github.com/real-web-world/hh-lol-prophet/services/metrics"
+)
+
+const (
+	chatMsgMaxLen       = 200                     // 单条聊天消息最大字符数
+	chatSendInterval    = time.Millisecond * 2100 // 发送间隔
+	chatSendBurst       = 1
+	chatSendMaxAttempts = 3
+	chatQueueSize       = 32
+)
+
+type (
+	// 单个聊天组的发送队列 按令牌桶限速发送
+	chatQueue struct {
+		conversationID string
+		cli            lcu.Api
+		ctx            context.Context
+		cancel         func()
+		limiter        *rate.Limiter
+		msgC           chan chatMsg
+		depth          atomic.Int64
+	}
+	chatMsg struct {
+		text       string
+		spanCtx    trace.SpanContext // 入队时的span 发送span作为其子span
+		enqueuedAt time.Time
+	}
+)
+
+func newChatQueue(ctx context.Context, cli lcu.Api, conversationID string) *chatQueue {
+	ctx, cancel := context.WithCancel(ctx)
+	q := &chatQueue{
+		conversationID: conversationID,
+		cli:            cli,
+		ctx:            ctx,
+		cancel:         cancel,
+		limiter:        rate.NewLimiter(rate.Every(chatSendInterval), chatSendBurst),
+		msgC:           make(chan chatMsg, chatQueueSize),
+	}
+	go q.run()
+	return q
+}
+
+// 超长消息按行拆分后入队 队列已满时丢弃
+func (q *chatQueue) push(ctx context.Context, msg string) {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	for _, item := range splitChatMsg(msg, chatMsgMaxLen) {
+		select {
+		case q.msgC <- chatMsg{text: item, spanCtx: spanCtx, enqueuedAt: time.Now()}:
+			depth := q.depth.Add(1)
+			logger.Debug("聊天消息入队", zap.String("conversationID", q.conversationID),
+				zap.Int64("depth", depth))
+		default:
+			metrics.IncChatMsg(metrics.ResultFail)
+			logger.Warn("聊天发送队列已满,丢弃消息", zap.String("conversationID", q.conversationID),
+				zap.Int64("depth", q.depth.Load()))
+		}
+	}
+}
+func (q *chatQueue) run() {
+	for {
+		select {
+		case <-q.ctx.Done():
+			if depth := q.depth.Load(); depth > 0 {
+				logger.Info("聊天发送队列已取消", zap.String("conversationID", q.conversationID),
+					zap.Int64("dropped", depth))
+			}
+			return
+		case msg := <-q.msgC:
+			if err := q.send(msg); err != nil {
+				if q.ctx.Err() == nil {
+					metrics.IncChatMsg(metrics.ResultFail)
+					logger.Warn("发送聊天消息失败", zap.Error(err), zap.String("conversationID", q.conversationID),
+						zap.Int64("depth", q.depth.Load()-1))
+				}
+			} else {
+				metrics.IncChatMsg(metrics.ResultSuccess)
+			}
+			q.depth.Add(-1)
+		}
+	}
+}
+func (q *chatQueue) send(msg chatMsg) (err error) {
+	ctx, span := tracer.Start(trace.ContextWithSpanContext(q.ctx, msg.spanCtx), "SendConversationMsg",
+		trace.WithAttributes(traceAttrConversationID.String(q.conversationID),
+			traceAttrChatMsgLen.Int(utf8.RuneCountInString(msg.text))))
+	defer func() {
+		endSpan(span, err)
+	}()
+	err = retry.Do(func() error {
+		if err := q.limiter.Wait(q.ctx); err != nil {
+			return retry.Unrecoverable(err)
+		}
+		// 排队及限速等待的总时长 用于排查消息发送延迟
+		span.SetAttributes(traceAttrChatQueueWait.Int64(time.Since(msg.enqueuedAt).Milliseconds()))
+		return SendConversationMsg(ctx, q.cli, msg.text, q.conversationID)
+	}, retry.Context(q.ctx), retry.Attempts(chatSendMaxAttempts), retry.Delay(time.Millisecond*500),
+		retry.LastErrorOnly(true), retry.RetryIf(lcu.IsTransientErr))
+	return err
+}
+func (q *chatQueue) stop() {
+	q.cancel()
+}
+
+// 按行拆分消息 每条不超过maxLen个字符 单行超长时按字符截断
+func splitChatMsg(msg string, maxLen int) []string {
+	msg = strings.TrimRight(msg, "\n")
+	if utf8.RuneCountInString(msg) <= maxLen {
+		return []string{msg}
+	}
+	res := make([]string, 0, 2)
+	sb := strings.Builder{}
+	sbLen := 0
+	flush := func() {
+		if sbLen > 0 {
+			res = append(res, sb.String())
+			sb.Reset()
+			sbLen = 0
+		}
+	}
+	for _, line := range strings.Split(msg, "\n") {
+		lineRunes := []rune(line)
+		for len(lineRunes) > maxLen {
+			flush()
+			res = append(res, string(lineRunes[:maxLen]))
+			lineRunes = lineRunes[maxLen:]
+		}
+		lineLen := len(lineRunes)
+		if sbLen > 0 && sbLen+1+lineLen > maxLen {
+			flush()
+		}
+		if sbLen > 0 {
+			sb.WriteString("\n")
+			sbLen++
+		}
+		sb.WriteString(string(lineRunes))
+		sbLen += lineLen
+	}
+	flush()
+	return res
+}
+
+// 仅在选人阶段发送 队列随选人阶段的ctx取消
+func (p *Prophet) sendChatMsg(ctx context.Context, conversationID, msg string) {
+	state, stateCtx := p.fsm.currentCtx()
+	if state != GameStateChampSelect || stateCtx.Err() != nil {
+		logger.Debug("已离开英雄选择阶段,不再发送聊天消息", zap.String("conversationID", conversationID))
+		return
+	}
+	p.mu.Lock()
+	q, ok := p.chatQueues[conversationID]
+	if !ok || q.ctx.Err() != nil {
+		q = newChatQueue(stateCtx, p.lcuCli, conversationID)
+		p.chatQueues[conversationID] = q
+	}
+	p.mu.Unlock()
+	q.push(ctx, msg)
+}
+func (p *Prophet) stopChatQueues() {
+	p.mu.Lock()
+	queues := p.chatQueues
+	p.chatQueues = make(map[string]*chatQueue)
+	p.mu.Unlock()
+	for _, q := range queues {
+		q.stop()
+	}
+}
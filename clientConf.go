@@ -0,0 +1,94 @@
+package hh_lol_prophet
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/db/models"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu"
+	"github.com/real-web-world/hh-lol-prophet/services/logger"
+)
+
+const (
+	championCacheTTL = time.Hour // 客户端更新可能新增英雄
+)
+
+var (
+	// 英雄id -> 英雄名称
+	championCache = struct {
+		mu        sync.Mutex
+		names     map[int]string
+		fetchedAt time.Time
+	}{}
+)
+
+// 校验并保存客户端配置 数据库写入成功后才修改内存中的配置
+func (p *Prophet) updateClientConf(ctx context.Context, req conf.UpdateClientUserConfReq) (*conf.ClientUserConf, error) {
+	championExists := p.getChampionExistsFn(ctx)
+	return global.UpdateClientUserConf(req, func(cfg conf.ClientUserConf) error {
+		if errs := conf.CheckClientUserConf(&cfg, championExists); len(errs) > 0 {
+			return errs
+		}
+		bts, err := json.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		return global.SqliteDB.Transaction(func(tx *gorm.DB) error {
+			m := models.Config{Tx: tx}
+			if err := m.Update(models.LocalClientConfKey, string(bts)); err != nil {
+				return err
+			}
+			return syncActiveClientProfile(m, cfg)
+		})
+	})
+}
+
+// lcu未连接或获取英雄列表失败时返回nil 不校验英雄是否存在
+func (p *Prophet) getChampionExistsFn(ctx context.Context) func(championID int) bool {
+	if !p.isLcuActive() {
+		return nil
+	}
+	names, err := listChampionNames(ctx, p.getLcuClient())
+	if err != nil {
+		logger.Debug("获取英雄列表失败,跳过英雄校验", zap.Error(err))
+		return nil
+	}
+	return func(championID int) bool {
+		_, ok := names[championID]
+		return ok
+	}
+}
+func listChampionNames(ctx context.Context, cli lcu.Api) (map[int]string, error) {
+	championCache.mu.Lock()
+	defer championCache.mu.Unlock()
+	if championCache.names != nil && time.Since(championCache.fetchedAt) < championCacheTTL {
+		return championCache.names, nil
+	}
+	list, err := cli.ListChampionSummary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(list))
+	for _, item := range list {
+		if item.Id > 0 {
+			names[item.Id] = item.Name
+		}
+	}
+	championCache.names = names
+	championCache.fetchedAt = time.Now()
+	return names, nil
+}
+
+// 仅从已获取的英雄列表中查找 未获取或未知英雄时返回空
+func getCachedChampionName(championID int) string {
+	championCache.mu.Lock()
+	defer championCache.mu.Unlock()
+	return championCache.names[championID]
+}
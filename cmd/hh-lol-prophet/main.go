@@ -32,10 +32,12 @@ var (
 	showVersion   = flag.Bool("v", false, "展示版本信息")
 	isUpdate      = flag.Bool("u", false, "是否是更新")
 	delUpgradeBin = flag.Bool("delUpgradeBin", false, "是否删除升级程序")
+	offline       = flag.Bool("offline", false, "离线模式 不请求远程配置 不上报日志 不检查更新")
 )
 
 func flagInit() {
 	flag.Parse()
+	global.SetOfflineMode(*offline)
 	if *showVersion {
 		log.Printf("当前版本:%s,commitID:%s,构建时间:%v\n", app.APPVersion,
 			app.Commit, app.BuildTime)
@@ -102,7 +104,7 @@ func removeUpgradeBinFile() error {
 	return os.Remove(binNewFullPath)
 }
 func checkUpdate() error {
-	if global.IsDevMode() {
+	if global.IsDevMode() || global.IsOfflineMode() {
 		return nil
 	}
 	var binNewFullPath string
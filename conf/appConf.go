@@ -1,11 +1,39 @@
 package conf
 
 import (
+	"encoding/json"
+	"os"
+
+	"github.com/jinzhu/configor"
+	"github.com/pkg/errors"
 	"go.opentelemetry.io/contrib/processors/minsev"
+	"go.uber.org/zap/zapcore"
 )
 
 const GetRemoteConfApi = "https://lol.buffge.com/api/v1/getAppConf"
 
+// 本地配置文件
+const (
+	EnvFileName       = ".env"
+	EnvLocalFileName  = ".env.local"
+	LocalConfFilePath = "./config.json"
+)
+
+// trace exporter
+const (
+	TraceExporterNone = "none"
+	TraceExporterOtlp = "otlp"
+	TraceExporterFile = "file"
+)
+
+// log file
+const (
+	DefaultLogFilePath       = "logs/prophet.log"
+	DefaultLogFileMaxSizeMB  = 10
+	DefaultLogFileMaxAgeDays = 7
+	DefaultLogFileMaxBackups = 5
+)
+
 // mode
 const (
 	ModeDebug Mode = "debug"
@@ -35,6 +63,7 @@ type (
 		AdaptChatWebsiteTitle string        `json:"adaptChatWebsiteTitle" default:"lol.buffge点康姆"`
 		ProjectUrl            string        `json:"projectUrl" default:"github.com/real-web-world/hh-lol-prophet"`
 		Otlp                  OtlpConf      `json:"otlp"`
+		Trace                 TraceConf     `json:"trace"`
 		WebView               WebViewConf   `json:"webView"`
 	}
 	WebViewConf struct {
@@ -42,12 +71,26 @@ type (
 	}
 	Mode    = string
 	LogConf struct {
-		Level string `json:"level" default:"info" env:"logLevel"`
+		Level string      `json:"level" default:"info" env:"logLevel"`
+		File  LogFileConf `json:"file"`
+	}
+	// 本地滚动日志文件 零值字段使用默认值
+	LogFileConf struct {
+		Disabled   bool   `json:"disabled" env:"logFileDisabled"`
+		Path       string `json:"path" env:"logFilePath"`
+		MaxSizeMB  int    `json:"maxSizeMB"`  // 单个文件大小上限
+		MaxAgeDays int    `json:"maxAgeDays"` // 历史文件保留天数
+		MaxBackups int    `json:"maxBackups"` // 历史文件保留个数
 	}
 	OtlpConf struct {
 		EndpointUrl string `json:"endpointUrl" default:"https://otlp-gateway-prod-ap-southeast-1.grafana.net/otlp"`
 		Token       string `json:"token" default:"ODE5OTIyOmdsY19leUp2SWpvaU16QXdOekkzSWl3aWJpSTZJbk4wWVdOckxUZ3hPVGt5TWkxdmRHeHdMWGR5YVhSbExXOTBiSEF0ZEc5clpXNHRNaUlzSW1zaU9pSTVORVl5TVdsS1pHdG9NVmN3VXpaaE1HczNhakZwYm1jaUxDSnRJanA3SW5JaU9pSndjbTlrTFdGd0xYTnZkWFJvWldGemRDMHhJbjE5"`
 	}
+	TraceConf struct {
+		Exporter    string  `json:"exporter" default:"none" env:"traceExporter"`        // none|otlp|file
+		FilePath    string  `json:"filePath" default:"trace.jsonl" env:"traceFilePath"` // exporter为file时的输出文件
+		SampleRatio float64 `json:"sampleRatio" default:"1"`
+	}
 	BuffApi struct {
 		Url     string `json:"url" default:"https://k2-api.buffge.com:40012/prod/lol" env:"buffApiUrl"`
 		Timeout int    `json:"timeout" default:"5"`
@@ -84,6 +127,59 @@ type (
 	}
 )
 
+// 深拷贝 防止修改时影响原配置中的slice及map
+func CloneAppConf(cfg AppConf) (AppConf, error) {
+	res := AppConf{}
+	bts, err := json.Marshal(cfg)
+	if err != nil {
+		return res, err
+	}
+	err = json.Unmarshal(bts, &res)
+	return res, err
+}
+
+// 在base上加载本地配置文件及环境变量并校验 base不会被修改
+func LoadLocalAppConf(base AppConf) (AppConf, error) {
+	cfg, err := CloneAppConf(base)
+	if err != nil {
+		return base, err
+	}
+	localConfFiles := make([]string, 0, 1)
+	if info, err := os.Stat(LocalConfFilePath); err == nil && !info.IsDir() {
+		localConfFiles = append(localConfFiles, LocalConfFilePath)
+	}
+	if err = configor.New(&configor.Config{Silent: true}).Load(&cfg, localConfFiles...); err != nil {
+		return base, errors.Wrap(errBadConf, err.Error())
+	}
+	if err = ValidAppConf(&cfg); err != nil {
+		return base, err
+	}
+	return cfg, nil
+}
+
+// 远程配置中可能没有该项 零值字段使用默认值
+func (c LogFileConf) WithDefault() LogFileConf {
+	if c.Path == "" {
+		c.Path = DefaultLogFilePath
+	}
+	if c.MaxSizeMB <= 0 {
+		c.MaxSizeMB = DefaultLogFileMaxSizeMB
+	}
+	if c.MaxAgeDays <= 0 {
+		c.MaxAgeDays = DefaultLogFileMaxAgeDays
+	}
+	if c.MaxBackups <= 0 {
+		c.MaxBackups = DefaultLogFileMaxBackups
+	}
+	return c
+}
+func LogLevel2Zap(levelStr string) zapcore.Level {
+	level, err := zapcore.ParseLevel(levelStr)
+	if err != nil {
+		return zapcore.InfoLevel
+	}
+	return level
+}
 func LogLevel2Otel(levelStr string) minsev.Severity {
 	level, exist := logLevelMapSeverity[levelStr]
 	if exist {
@@ -1,13 +1,34 @@
 package conf
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
 
 const (
 	SqliteDBPath = "prophet.db"
 )
 
+// 默认消息模板
+const (
+	DefaultTeamHorseMsgTpl   = `{{.Horse}}({{.Score}}): {{.RiotID}} {{.KDA}}`
+	DefaultMergedHorseMsgTpl = `{{range .Players}}{{.Horse}}({{.Score}}): {{.RiotID}} {{.KDA}}
+{{end}}`
+	DefaultEnemyHorseMsgTpl = `{{range .Players}}{{.Horse}}({{.Score}}): {{.RiotID}} {{.KDA}}  -- {{$.WebsiteTitle}}
+{{end}}`
+)
+
 var (
 	errBadConf = errors.New("错误的配置")
+	// 默认允许官方网站跨域访问
+	DefaultAllowOriginList = []string{"*.buffge.com"}
+	// 开发模式额外允许本地前端开发服务器 任意端口
+	DevAllowOriginList = []string{"localhost", "127.0.0.1"}
 )
 
 type (
@@ -22,6 +43,34 @@ type (
 		ChooseChampSendMsgDelaySec     int       `json:"chooseChampSendMsgDelaySec"`     // 选人阶段延迟几秒发送
 		ShouldInGameSaveMsgToClipBoard bool      `json:"shouldInGameSaveMsgToClipBoard"` // 进入对局后保存敌方马匹消息到剪切板中
 		ShouldAutoOpenBrowser          *bool     `json:"shouldAutoOpenBrowser"`          // 是否自动打开浏览器
+		AutoSetRuneAndSpell            bool      `json:"autoSetRuneAndSpell"`            // 锁定英雄后自动设置符文及召唤师技能
+		AramAutoSwap                   bool      `json:"aramAutoSwap"`                   // 大乱斗自动从备选席换英雄
+		AramChampPriority              []int     `json:"aramChampPriority"`              // 大乱斗英雄优先级 越靠前越优先
+		AramAutoReroll                 bool      `json:"aramAutoReroll"`                 // 大乱斗无心仪英雄时自动重随
+		AramSwapDelayMs                int       `json:"aramSwapDelayMs"`                // 大乱斗换英雄延迟毫秒
+		AutoAcceptDelaySec             [2]int    `json:"autoAcceptDelaySec"`             // 自动接受延迟秒数 [最小,最大] 在区间内随机
+		AutoAcceptQueueIDList          []int     `json:"autoAcceptQueueIDList"`          // 仅在这些队列自动接受 为空时不限制
+		AutoAcceptOnlyFullLobby        bool      `json:"autoAcceptOnlyFullLobby"`        // 仅在房间满员时自动接受
+		AutoDeclineQueueIDList         []int     `json:"autoDeclineQueueIDList"`         // 自动拒绝的队列
+		AutoSkipHonor                  bool      `json:"autoSkipHonor"`                  // 结算前自动跳过点赞
+		AutoHonorRiotIDList            []string  `json:"autoHonorRiotIDList"`            // 自动点赞的队友 gameName#tagLine
+		AutoPlayAgain                  bool      `json:"autoPlayAgain"`                  // 结算后自动关闭结算界面并返回房间
+		AutoRequeue                    bool      `json:"autoRequeue"`                    // 返回房间后自动开始匹配
+		AutoRequeueDelaySec            int       `json:"autoRequeueDelaySec"`            // 自动开始匹配延迟秒数
+		AutoRequeueStopAfterLosses     int       `json:"autoRequeueStopAfterLosses"`     // 连败几局后停止自动匹配 0为不限制
+		TeamHorseMsgTpl                string    `json:"teamHorseMsgTpl"`                // 选人阶段每个队友的消息模板
+		MergedHorseMsgTpl              string    `json:"mergedHorseMsgTpl"`              // 选人阶段合并后的队伍消息模板
+		EnemyHorseMsgTpl               string    `json:"enemyHorseMsgTpl"`               // 游戏中敌方马匹信息模板
+		GameReportToClipBoard          bool      `json:"gameReportToClipBoard"`          // 结算后将赛后报告复制到剪切板
+		LocalApiTokenEnabled           bool      `json:"localApiTokenEnabled"`           // 敏感接口需携带本地api token
+		AllowOriginList                []string  `json:"allowOriginList"`                // 允许跨域的来源 支持*.example.com及完整origin 为空时使用默认值
+		OfflineMode                    bool      `json:"offlineMode"`                    // 离线模式 不请求远程配置 不上报日志 不检查更新 重启后生效
+	}
+	// 命名的客户端配置方案
+	ClientProfile struct {
+		Name        string         `json:"name"`
+		QueueIDList []int          `json:"queueIDList"` // 大厅切换到这些队列时自动切换到该方案 为空时不自动切换
+		Conf        ClientUserConf `json:"conf"`
 	}
 	UpdateClientUserConfReq struct {
 		AutoAcceptGame                 *bool      `json:"autoAcceptGame"`
@@ -34,14 +83,135 @@ type (
 		ChooseChampSendMsgDelaySec     *int       `json:"chooseChampSendMsgDelaySec"`
 		ShouldInGameSaveMsgToClipBoard *bool      `json:"shouldInGameSaveMsgToClipBoard"`
 		ShouldAutoOpenBrowser          *bool      `json:"shouldAutoOpenBrowser"`
+		AutoSetRuneAndSpell            *bool      `json:"autoSetRuneAndSpell"`
+		AramAutoSwap                   *bool      `json:"aramAutoSwap"`
+		AramChampPriority              *[]int     `json:"aramChampPriority"`
+		AramAutoReroll                 *bool      `json:"aramAutoReroll"`
+		AramSwapDelayMs                *int       `json:"aramSwapDelayMs"`
+		AutoAcceptDelaySec             *[2]int    `json:"autoAcceptDelaySec"`
+		AutoAcceptQueueIDList          *[]int     `json:"autoAcceptQueueIDList"`
+		AutoAcceptOnlyFullLobby        *bool      `json:"autoAcceptOnlyFullLobby"`
+		AutoDeclineQueueIDList         *[]int     `json:"autoDeclineQueueIDList"`
+		AutoSkipHonor                  *bool      `json:"autoSkipHonor"`
+		AutoHonorRiotIDList            *[]string  `json:"autoHonorRiotIDList"`
+		AutoPlayAgain                  *bool      `json:"autoPlayAgain"`
+		AutoRequeue                    *bool      `json:"autoRequeue"`
+		AutoRequeueDelaySec            *int       `json:"autoRequeueDelaySec"`
+		AutoRequeueStopAfterLosses     *int       `json:"autoRequeueStopAfterLosses"`
+		TeamHorseMsgTpl                *string    `json:"teamHorseMsgTpl"`
+		MergedHorseMsgTpl              *string    `json:"mergedHorseMsgTpl"`
+		EnemyHorseMsgTpl               *string    `json:"enemyHorseMsgTpl"`
+		GameReportToClipBoard          *bool      `json:"gameReportToClipBoard"`
+		LocalApiTokenEnabled           *bool      `json:"localApiTokenEnabled"`
+		AllowOriginList                *[]string  `json:"allowOriginList"`
+		OfflineMode                    *bool      `json:"offlineMode"`
 	}
+	// 单个配置项的校验错误 field为json字段名 数组元素带下标
+	ClientConfFieldErr struct {
+		Field string `json:"field"`
+		Msg   string `json:"msg"`
+	}
+	ClientConfValidErr []ClientConfFieldErr
 )
 
+func (e ClientConfValidErr) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, item := range e {
+		msgs = append(msgs, item.Field+": "+item.Msg)
+	}
+	return strings.Join(msgs, "; ")
+}
+
 func ValidClientUserConf(cfg *ClientUserConf) error {
 	for _, s := range cfg.HorseNameConf {
 		if s == "" {
 			return errBadConf
 		}
 	}
+	for _, tpl := range []string{cfg.TeamHorseMsgTpl, cfg.MergedHorseMsgTpl, cfg.EnemyHorseMsgTpl} {
+		if err := ValidMsgTpl(tpl); err != nil {
+			return errors.Wrap(errBadConf, err.Error())
+		}
+	}
 	return nil
 }
+func ValidMsgTpl(tpl string) error {
+	_, err := template.New("").Parse(tpl)
+	return err
+}
+
+// 保存配置前的完整校验 数值范围取自schema
+// championExists为nil时不校验英雄是否存在
+func CheckClientUserConf(cfg *ClientUserConf, championExists func(championID int) bool) ClientConfValidErr {
+	errs := make(ClientConfValidErr, 0)
+	addErr := func(field, format string, args ...any) {
+		errs = append(errs, ClientConfFieldErr{Field: field, Msg: fmt.Sprintf(format, args...)})
+	}
+	t := reflect.TypeOf(*cfg)
+	v := reflect.ValueOf(*cfg)
+	for i := 0; i < t.NumField(); i++ {
+		key := clientConfFieldKey(t.Field(i))
+		meta := clientConfFieldMetas[key]
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Int:
+			checkClientConfIntRange(key, int(field.Int()), meta, addErr)
+		case reflect.Array, reflect.Slice:
+			if field.Type().Elem().Kind() != reflect.Int {
+				continue
+			}
+			for j := 0; j < field.Len(); j++ {
+				checkClientConfIntRange(fmt.Sprintf("%s[%d]", key, j), int(field.Index(j).Int()), meta, addErr)
+			}
+		default:
+		}
+	}
+	for i, s := range cfg.HorseNameConf {
+		if strings.TrimSpace(s) == "" {
+			addErr(fmt.Sprintf("horseNameConf[%d]", i), "马匹名称不能为空")
+		}
+	}
+	for key, tpl := range map[string]string{"teamHorseMsgTpl": cfg.TeamHorseMsgTpl,
+		"mergedHorseMsgTpl": cfg.MergedHorseMsgTpl, "enemyHorseMsgTpl": cfg.EnemyHorseMsgTpl} {
+		if err := ValidMsgTpl(tpl); err != nil {
+			addErr(key, "消息模板错误:%s", err.Error())
+		}
+	}
+	if cfg.AutoAcceptDelaySec[0] > cfg.AutoAcceptDelaySec[1] {
+		addErr("autoAcceptDelaySec", "最小值不能大于最大值")
+	}
+	for i, riotID := range cfg.AutoHonorRiotIDList {
+		if idx := strings.LastIndex(riotID, "#"); idx <= 0 || idx == len(riotID)-1 {
+			addErr(fmt.Sprintf("autoHonorRiotIDList[%d]", i), "riot id格式错误,应为 gameName#tagLine")
+		}
+	}
+	if championExists != nil {
+		for key, championID := range map[string]int{"autoPickChampID": cfg.AutoPickChampID,
+			"autoBanChampID": cfg.AutoBanChampID} {
+			if championID > 0 && !championExists(championID) {
+				addErr(key, "英雄%d不存在", championID)
+			}
+		}
+		for i, championID := range cfg.AramChampPriority {
+			if !championExists(championID) {
+				addErr(fmt.Sprintf("aramChampPriority[%d]", i), "英雄%d不存在", championID)
+			}
+		}
+	}
+	// map遍历无序 按字段名排序保证返回稳定
+	slices.SortStableFunc(errs, func(a, b ClientConfFieldErr) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return errs
+}
+func checkClientConfIntRange(field string, val int, meta clientConfFieldMeta,
+	addErr func(field, format string, args ...any)) {
+	switch {
+	case meta.Min != nil && meta.Max != nil && (val < *meta.Min || val > *meta.Max):
+		addErr(field, "需在%d到%d之间", *meta.Min, *meta.Max)
+	case meta.Min != nil && val < *meta.Min:
+		addErr(field, "不能小于%d", *meta.Min)
+	case meta.Max != nil && val > *meta.Max:
+		addErr(field, "不能大于%d", *meta.Max)
+	}
+}
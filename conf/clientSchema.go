@@ -0,0 +1,264 @@
+package conf
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// 配置分组
+const (
+	ClientConfGroupChampSelect = "champSelect"
+	ClientConfGroupHorseMsg    = "horseMsg"
+	ClientConfGroupAram        = "aram"
+	ClientConfGroupReadyCheck  = "readyCheck"
+	ClientConfGroupPostGame    = "postGame"
+	ClientConfGroupApp         = "app"
+)
+
+// 前端控件 为空时按type渲染
+const (
+	ClientConfWidgetChampion  = "champion"  // 英雄id
+	ClientConfWidgetQueue     = "queue"     // 队列id
+	ClientConfWidgetMsgTpl    = "msgTpl"    // 消息模板 可调用config/previewMsgTpl预览
+	ClientConfWidgetRiotID    = "riotID"    // gameName#tagLine
+	ClientConfWidgetOrigin    = "origin"    // 跨域来源
+	ClientConfWidgetHorseName = "horseName" // 按马匹等级排列
+)
+
+var (
+	ClientConfGroups = []ClientConfGroup{
+		{Key: ClientConfGroupChampSelect, Label: I18nText{Zh: "英雄选择", En: "Champion select"}},
+		{Key: ClientConfGroupHorseMsg, Label: I18nText{Zh: "马匹消息", En: "Horse messages"}},
+		{Key: ClientConfGroupAram, Label: I18nText{Zh: "大乱斗", En: "ARAM"}},
+		{Key: ClientConfGroupReadyCheck, Label: I18nText{Zh: "接受对局", En: "Ready check"}},
+		{Key: ClientConfGroupPostGame, Label: I18nText{Zh: "结算", En: "Post game"}},
+		{Key: ClientConfGroupApp, Label: I18nText{Zh: "应用", En: "Application"}},
+	}
+	// key为json字段名 ClientUserConf新增字段时需在此补充
+	clientConfFieldMetas = map[string]clientConfFieldMeta{
+		"autoAcceptGame": {Group: ClientConfGroupReadyCheck,
+			Label: I18nText{Zh: "自动接受对局", En: "Auto accept"},
+			Desc:  I18nText{Zh: "找到对局后自动接受", En: "Accept the match automatically when found"}},
+		"autoPickChampID": {Group: ClientConfGroupChampSelect, Widget: ClientConfWidgetChampion, Min: intPtr(0),
+			Label: I18nText{Zh: "自动秒选", En: "Auto pick"},
+			Desc:  I18nText{Zh: "轮到自己选人时自动锁定该英雄 0为关闭", En: "Lock in this champion on your pick turn, 0 to disable"}},
+		"autoBanChampID": {Group: ClientConfGroupChampSelect, Widget: ClientConfWidgetChampion, Min: intPtr(0),
+			Label: I18nText{Zh: "自动禁用", En: "Auto ban"},
+			Desc:  I18nText{Zh: "轮到自己禁用时自动禁用该英雄 0为关闭", En: "Ban this champion on your ban turn, 0 to disable"}},
+		"autoSendTeamHorse": {Group: ClientConfGroupHorseMsg,
+			Label: I18nText{Zh: "自动发送队友马匹", En: "Send team horses"},
+			Desc:  I18nText{Zh: "选人阶段自动发送队友马匹信息到聊天", En: "Post teammates' horse info to champ select chat"}},
+		"shouldSendSelfHorse": {Group: ClientConfGroupHorseMsg,
+			Label: I18nText{Zh: "发送自己的马匹", En: "Include myself"},
+			Desc:  I18nText{Zh: "发送马匹信息时包含自己", En: "Include your own horse info in the message"}},
+		"horseNameConf": {Group: ClientConfGroupHorseMsg, Widget: ClientConfWidgetHorseName,
+			Label: I18nText{Zh: "马匹名称", En: "Horse names"},
+			Desc:  I18nText{Zh: "从高到低各等级马匹的名称 不能为空", En: "Names of each horse tier from best to worst, must not be empty"}},
+		"chooseSendHorseMsg": {Group: ClientConfGroupHorseMsg, Widget: ClientConfWidgetHorseName,
+			Label: I18nText{Zh: "发送哪些马匹", En: "Tiers to send"},
+			Desc:  I18nText{Zh: "按等级选择是否发送", En: "Choose which horse tiers are sent"}},
+		"chooseChampSendMsgDelaySec": {Group: ClientConfGroupHorseMsg, Min: intPtr(0), Max: intPtr(30),
+			Label: I18nText{Zh: "发送延迟(秒)", En: "Send delay (s)"},
+			Desc:  I18nText{Zh: "进入选人阶段后延迟几秒发送", En: "Seconds to wait after entering champ select"}},
+		"shouldInGameSaveMsgToClipBoard": {Group: ClientConfGroupHorseMsg,
+			Label: I18nText{Zh: "敌方马匹复制到剪切板", En: "Copy enemy horses"},
+			Desc:  I18nText{Zh: "进入游戏后将敌方马匹信息复制到剪切板", En: "Copy enemy horse info to the clipboard when the game starts"}},
+		"shouldAutoOpenBrowser": {Group: ClientConfGroupApp,
+			Label: I18nText{Zh: "自动打开浏览器", En: "Open browser"},
+			Desc:  I18nText{Zh: "启动后自动打开网页界面", En: "Open the web UI on startup"}},
+		"autoSetRuneAndSpell": {Group: ClientConfGroupChampSelect,
+			Label: I18nText{Zh: "自动设置符文及技能", En: "Auto runes and spells"},
+			Desc:  I18nText{Zh: "锁定英雄后按英雄符文配置设置符文页及召唤师技能", En: "Apply the saved rune page and summoner spells after lock-in"}},
+		"aramAutoSwap": {Group: ClientConfGroupAram,
+			Label: I18nText{Zh: "自动换英雄", En: "Auto swap"},
+			Desc:  I18nText{Zh: "备选席出现优先级更高的英雄时自动交换", En: "Swap with a higher priority champion from the bench"}},
+		"aramChampPriority": {Group: ClientConfGroupAram, Widget: ClientConfWidgetChampion, Min: intPtr(1),
+			Label: I18nText{Zh: "英雄优先级", En: "Champion priority"},
+			Desc:  I18nText{Zh: "越靠前越优先", En: "Earlier champions are preferred"}},
+		"aramAutoReroll": {Group: ClientConfGroupAram,
+			Label: I18nText{Zh: "自动重随", En: "Auto reroll"},
+			Desc:  I18nText{Zh: "没有心仪英雄时自动重随", En: "Reroll when no preferred champion is available"}},
+		"aramSwapDelayMs": {Group: ClientConfGroupAram, Min: intPtr(0), Max: intPtr(10000),
+			Label: I18nText{Zh: "换英雄延迟(毫秒)", En: "Swap delay (ms)"},
+			Desc:  I18nText{Zh: "实际延迟会在此基础上随机增加", En: "A random jitter is added to this delay"}},
+		"autoAcceptDelaySec": {Group: ClientConfGroupReadyCheck, Min: intPtr(0), Max: intPtr(9),
+			Label: I18nText{Zh: "接受延迟(秒)", En: "Accept delay (s)"},
+			Desc:  I18nText{Zh: "[最小,最大] 在区间内随机", En: "[min, max], a random value in the range is used"}},
+		"autoAcceptQueueIDList": {Group: ClientConfGroupReadyCheck, Widget: ClientConfWidgetQueue,
+			Label: I18nText{Zh: "自动接受的队列", En: "Queues to accept"},
+			Desc:  I18nText{Zh: "仅在这些队列自动接受 为空时不限制", En: "Only accept in these queues, empty for all"}},
+		"autoAcceptOnlyFullLobby": {Group: ClientConfGroupReadyCheck,
+			Label: I18nText{Zh: "仅满员时接受", En: "Full lobby only"},
+			Desc:  I18nText{Zh: "仅在房间满员时自动接受", En: "Only accept when the lobby is full"}},
+		"autoDeclineQueueIDList": {Group: ClientConfGroupReadyCheck, Widget: ClientConfWidgetQueue,
+			Label: I18nText{Zh: "自动拒绝的队列", En: "Queues to decline"},
+			Desc:  I18nText{Zh: "在这些队列找到对局时自动拒绝", En: "Decline matches found in these queues"}},
+		"autoSkipHonor": {Group: ClientConfGroupPostGame,
+			Label: I18nText{Zh: "跳过点赞", En: "Skip honor"},
+			Desc:  I18nText{Zh: "结算前自动跳过点赞", En: "Skip the honor vote after the game"}},
+		"autoHonorRiotIDList": {Group: ClientConfGroupPostGame, Widget: ClientConfWidgetRiotID,
+			Label: I18nText{Zh: "自动点赞的队友", En: "Auto honor"},
+			Desc:  I18nText{Zh: "与这些队友同队时自动点赞", En: "Honor these players when they are your teammates"}},
+		"autoPlayAgain": {Group: ClientConfGroupPostGame,
+			Label: I18nText{Zh: "自动返回房间", En: "Play again"},
+			Desc:  I18nText{Zh: "结算后自动关闭结算界面并返回房间", En: "Close the end of game screen and return to the lobby"}},
+		"autoRequeue": {Group: ClientConfGroupPostGame,
+			Label: I18nText{Zh: "自动开始匹配", En: "Auto requeue"},
+			Desc:  I18nText{Zh: "返回房间后自动开始匹配", En: "Start matchmaking after returning to the lobby"}},
+		"autoRequeueDelaySec": {Group: ClientConfGroupPostGame, Min: intPtr(0), Max: intPtr(60),
+			Label: I18nText{Zh: "匹配延迟(秒)", En: "Requeue delay (s)"},
+			Desc:  I18nText{Zh: "返回房间后延迟几秒开始匹配", En: "Seconds to wait before starting matchmaking"}},
+		"autoRequeueStopAfterLosses": {Group: ClientConfGroupPostGame, Min: intPtr(0), Max: intPtr(20),
+			Label: I18nText{Zh: "连败停止匹配", En: "Stop after losses"},
+			Desc:  I18nText{Zh: "连败几局后停止自动匹配 0为不限制", En: "Stop requeueing after this many losses in a row, 0 for no limit"}},
+		"teamHorseMsgTpl": {Group: ClientConfGroupHorseMsg, Widget: ClientConfWidgetMsgTpl,
+			Label: I18nText{Zh: "队友消息模板", En: "Teammate template"},
+			Desc:  I18nText{Zh: "选人阶段每个队友的消息", En: "Message for each teammate in champ select"}},
+		"mergedHorseMsgTpl": {Group: ClientConfGroupHorseMsg, Widget: ClientConfWidgetMsgTpl,
+			Label: I18nText{Zh: "合并消息模板", En: "Merged template"},
+			Desc:  I18nText{Zh: "开启合并消息时整个队伍的消息", En: "Message for the whole team when messages are merged"}},
+		"enemyHorseMsgTpl": {Group: ClientConfGroupHorseMsg, Widget: ClientConfWidgetMsgTpl,
+			Label: I18nText{Zh: "敌方消息模板", En: "Enemy template"},
+			Desc:  I18nText{Zh: "游戏中敌方马匹信息", En: "Enemy horse info during the game"}},
+		"gameReportToClipBoard": {Group: ClientConfGroupPostGame,
+			Label: I18nText{Zh: "赛后报告复制到剪切板", En: "Copy game report"},
+			Desc:  I18nText{Zh: "结算后将赛后报告复制到剪切板", En: "Copy the post game report to the clipboard"}},
+		"localApiTokenEnabled": {Group: ClientConfGroupApp,
+			Label: I18nText{Zh: "本地api token", En: "Local API token"},
+			Desc:  I18nText{Zh: "敏感接口需携带本地api token", En: "Require the local API token for sensitive endpoints"}},
+		"allowOriginList": {Group: ClientConfGroupApp, Widget: ClientConfWidgetOrigin,
+			Label: I18nText{Zh: "允许跨域的来源", En: "Allowed origins"},
+			Desc:  I18nText{Zh: "支持*.example.com及完整origin 为空时使用默认值", En: "*.example.com or a full origin, empty for the default"}},
+		"offlineMode": {Group: ClientConfGroupApp, NeedRestart: true,
+			Label: I18nText{Zh: "离线模式", En: "Offline mode"},
+			Desc:  I18nText{Zh: "不请求远程配置 不上报日志 不检查更新", En: "No remote config, telemetry or update check"}},
+	}
+)
+
+type (
+	I18nText struct {
+		Zh string `json:"zh"`
+		En string `json:"en"`
+	}
+	ClientConfGroup struct {
+		Key   string   `json:"key"`
+		Label I18nText `json:"label"`
+	}
+	clientConfFieldMeta struct {
+		Group       string
+		Label       I18nText
+		Desc        I18nText
+		Widget      string
+		Min         *int // 数字或数字数组元素的范围
+		Max         *int
+		NeedRestart bool
+	}
+	// 单个配置项 type为boolean|integer|string|array
+	ClientConfFieldSchema struct {
+		Key         string   `json:"key"`
+		Type        string   `json:"type"`
+		ItemType    string   `json:"itemType,omitempty"` // type为array时元素的类型
+		Len         int      `json:"len,omitempty"`      // 固定长度数组的长度
+		Nullable    bool     `json:"nullable"`
+		Default     any      `json:"default"`
+		Min         *int     `json:"min,omitempty"`
+		Max         *int     `json:"max,omitempty"`
+		Group       string   `json:"group"`
+		Widget      string   `json:"widget,omitempty"`
+		NeedRestart bool     `json:"needRestart"` // 修改后需重启生效
+		Label       I18nText `json:"label"`
+		Desc        I18nText `json:"desc"`
+	}
+	ClientConfSchema struct {
+		Groups []ClientConfGroup       `json:"groups"`
+		Fields []ClientConfFieldSchema `json:"fields"` // 按ClientUserConf字段顺序
+	}
+)
+
+// 类型由ClientUserConf反射得到 默认值取自defaultConf
+func GenClientConfSchema(defaultConf ClientUserConf) (*ClientConfSchema, error) {
+	t := reflect.TypeOf(defaultConf)
+	v := reflect.ValueOf(defaultConf)
+	fields := make([]ClientConfFieldSchema, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		key := clientConfFieldKey(t.Field(i))
+		meta, ok := clientConfFieldMetas[key]
+		if !ok {
+			return nil, errors.Errorf("配置项%s缺少schema", key)
+		}
+		fieldType := t.Field(i).Type
+		fieldVal := v.Field(i)
+		item := ClientConfFieldSchema{
+			Key:         key,
+			Min:         meta.Min,
+			Max:         meta.Max,
+			Group:       meta.Group,
+			Widget:      meta.Widget,
+			NeedRestart: meta.NeedRestart,
+			Label:       meta.Label,
+			Desc:        meta.Desc,
+		}
+		if fieldType.Kind() == reflect.Pointer {
+			item.Nullable = true
+			fieldType = fieldType.Elem()
+			if !fieldVal.IsNil() {
+				item.Default = fieldVal.Elem().Interface()
+			}
+		} else {
+			item.Default = fieldVal.Interface()
+		}
+		item.Type = clientConfFieldType(fieldType)
+		switch fieldType.Kind() {
+		case reflect.Array:
+			item.Len = fieldType.Len()
+			item.ItemType = clientConfFieldType(fieldType.Elem())
+		case reflect.Slice:
+			item.ItemType = clientConfFieldType(fieldType.Elem())
+		default:
+		}
+		fields = append(fields, item)
+	}
+	return &ClientConfSchema{
+		Groups: ClientConfGroups,
+		Fields: fields,
+	}, nil
+}
+
+// 返回缺少schema的配置项
+func ListClientConfMissingMeta() []string {
+	t := reflect.TypeOf(ClientUserConf{})
+	missing := make([]string, 0)
+	for i := 0; i < t.NumField(); i++ {
+		key := clientConfFieldKey(t.Field(i))
+		if _, ok := clientConfFieldMetas[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+func clientConfFieldKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+func clientConfFieldType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return t.Kind().String()
+	}
+}
+func intPtr(i int) *int {
+	return &i
+}
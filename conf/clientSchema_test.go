@@ -0,0 +1,22 @@
+package conf_test
+
+import (
+	"testing"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/global"
+)
+
+// 新增配置项必须补充schema
+func TestClientConfSchema(t *testing.T) {
+	if missing := conf.ListClientConfMissingMeta(); len(missing) > 0 {
+		t.Fatalf("以下配置项缺少schema: %v", missing)
+	}
+	schema, err := conf.GenClientConfSchema(global.DefaultClientUserConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Fields) == 0 {
+		t.Fatal("schema没有配置项")
+	}
+}
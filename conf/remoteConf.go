@@ -0,0 +1,164 @@
+package conf
+
+import (
+	"crypto/ed25519"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/jinzhu/configor"
+	"github.com/pkg/errors"
+)
+
+var (
+	// 远程配置签名公钥(base64) 服务端使用对应私钥对data原文签名 为空时不启用远程配置
+	//go:embed remoteConf.pub
+	remoteConfPublicKey string
+	// 未内置公钥时是否使用未签名的远程配置 构建时通过ldflags设置为true
+	AllowUnsignedRemoteConf = "false"
+
+	ErrRemoteConfSign = errors.New("远程配置签名校验失败")
+)
+
+// 是否已内置签名公钥 内置后必须校验签名
+func RemoteConfSignEnabled() bool {
+	return strings.TrimSpace(remoteConfPublicKey) != ""
+}
+
+// 未内置公钥且构建时未允许未签名配置时不请求远程配置
+func RemoteConfEnabled() bool {
+	return RemoteConfSignEnabled() || AllowUnsignedRemoteConf == "true"
+}
+
+// 校验远程配置data原文的ed25519签名 sign为base64
+func VerifyRemoteConfSign(data []byte, sign string) error {
+	pubKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(remoteConfPublicKey))
+	if err != nil || len(pubKey) != ed25519.PublicKeySize {
+		return errors.Wrap(ErrRemoteConfSign, "公钥错误")
+	}
+	sig, err := base64.StdEncoding.DecodeString(sign)
+	if err != nil || !ed25519.Verify(pubKey, data, sig) {
+		return ErrRemoteConfSign
+	}
+	return nil
+}
+
+// 将远程配置逐字段合并到base上 远程配置中没有的字段保留base的值
+// 列表字段单独合并 远程为空列表时保留base的值
+// 合并后的配置校验失败时返回错误 base不会被修改
+func MergeRemoteConf(base AppConf, data []byte) (AppConf, error) {
+	merged, err := CloneAppConf(base)
+	if err != nil {
+		return base, err
+	}
+	if err = json.Unmarshal(data, &merged); err != nil {
+		return base, errors.Wrap(err, "远程配置格式错误")
+	}
+	remote := remoteConfLists{}
+	if err = json.Unmarshal(data, &remote); err != nil {
+		return base, errors.Wrap(err, "远程配置格式错误")
+	}
+	// 保留的列表使用base的副本 避免与base共用底层数组
+	orig, err := CloneAppConf(base)
+	if err != nil {
+		return base, err
+	}
+	if err = mergeCalcScoreLists(&merged.CalcScore, orig.CalcScore, remote.CalcScore); err != nil {
+		return base, err
+	}
+	if err = ValidAppConf(&merged); err != nil {
+		return base, err
+	}
+	return merged, nil
+}
+
+type (
+	// 远程配置中的列表字段 与本地配置逐项合并
+	remoteConfLists struct {
+		CalcScore remoteCalcScoreLists `json:"calcScore"`
+	}
+	remoteCalcScoreLists struct {
+		AllowQueueIDList []int            `json:"allowQueueIDList"`
+		MinionsKilled    [][2]float64     `json:"minionsKilled"`
+		KillRate         []RateItemConf   `json:"killRate"`
+		HurtRate         []RateItemConf   `json:"hurtRate"`
+		AssistRate       []RateItemConf   `json:"assistRate"`
+		Horse            []HorseScoreConf `json:"horse"`
+	}
+)
+
+func mergeCalcScoreLists(merged *CalcScoreConf, base CalcScoreConf, remote remoteCalcScoreLists) error {
+	merged.AllowQueueIDList = mergeList(base.AllowQueueIDList, remote.AllowQueueIDList)
+	merged.MinionsKilled = mergeList(base.MinionsKilled, remote.MinionsKilled)
+	merged.KillRate = mergeList(base.KillRate, remote.KillRate)
+	merged.HurtRate = mergeList(base.HurtRate, remote.HurtRate)
+	merged.AssistRate = mergeList(base.AssistRate, remote.AssistRate)
+	switch len(remote.Horse) {
+	case 0:
+		merged.Horse = base.Horse
+	case len(merged.Horse):
+		copy(merged.Horse[:], remote.Horse)
+	default:
+		return errors.Wrapf(errBadConf, "calcScore.horse需为%d项", len(merged.Horse))
+	}
+	return nil
+}
+
+// 远程列表为空时保留base的值
+func mergeList[T any](base, remote []T) []T {
+	if len(remote) == 0 {
+		return base
+	}
+	return remote
+}
+
+// 计分和上报配置的完整性校验 及与本地配置相同的required校验
+// 完整性校验需在configor填充默认值之前 否则被清空的字段会被默认值掩盖
+func ValidAppConf(cfg *AppConf) error {
+	if cfg.AppName == "" {
+		return errors.Wrap(errBadConf, "appName为空")
+	}
+	if cfg.Mode != ModeDebug && cfg.Mode != ModeProd {
+		return errors.Wrap(errBadConf, "mode错误")
+	}
+	if err := validCalcScoreConf(cfg.CalcScore); err != nil {
+		return err
+	}
+	if err := validOtlpConf(cfg.Otlp); err != nil {
+		return err
+	}
+	if err := configor.New(&configor.Config{Silent: true}).Load(cfg); err != nil {
+		return errors.Wrap(errBadConf, err.Error())
+	}
+	return nil
+}
+func validCalcScoreConf(cfg CalcScoreConf) error {
+	if len(cfg.AllowQueueIDList) == 0 {
+		return errors.Wrap(errBadConf, "calcScore.allowQueueIDList为空")
+	}
+	if len(cfg.MinionsKilled) == 0 || len(cfg.KillRate) == 0 || len(cfg.HurtRate) == 0 ||
+		len(cfg.AssistRate) == 0 {
+		return errors.Wrap(errBadConf, "calcScore评分列表为空")
+	}
+	for i, horse := range cfg.Horse {
+		if horse.Name == "" || horse.Score <= 0 {
+			return errors.Wrap(errBadConf, "calcScore.horse配置不完整")
+		}
+		if i > 0 && horse.Score >= cfg.Horse[i-1].Score {
+			return errors.Wrap(errBadConf, "calcScore.horse分数需从高到低")
+		}
+	}
+	return nil
+}
+func validOtlpConf(cfg OtlpConf) error {
+	u, err := url.Parse(cfg.EndpointUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Wrap(errBadConf, "otlp.endpointUrl错误")
+	}
+	if cfg.Token == "" {
+		return errors.Wrap(errBadConf, "otlp.token为空")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package conf_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/global"
+)
+
+// 远程配置中的空列表不能清空本地列表 项数不对的马匹配置直接拒绝
+func TestMergeRemoteConfLists(t *testing.T) {
+	base, err := conf.LoadLocalAppConf(global.DefaultAppConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, err := conf.MergeRemoteConf(base, []byte(`{"calcScore":{"horse":[],"killRate":[],"allowQueueIDList":null}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.CalcScore.Horse != base.CalcScore.Horse ||
+		!reflect.DeepEqual(merged.CalcScore.KillRate, base.CalcScore.KillRate) ||
+		!reflect.DeepEqual(merged.CalcScore.AllowQueueIDList, base.CalcScore.AllowQueueIDList) {
+		t.Fatal("空列表覆盖了本地配置")
+	}
+	merged, err = conf.MergeRemoteConf(base, []byte(`{"calcScore":{"allowQueueIDList":[420]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(merged.CalcScore.AllowQueueIDList, []int{420}) {
+		t.Fatalf("远程列表未生效: %v", merged.CalcScore.AllowQueueIDList)
+	}
+	if _, err = conf.MergeRemoteConf(base, []byte(`{"calcScore":{"horse":[{"name":"a","score":1}]}}`)); err == nil {
+		t.Fatal("马匹配置项数不对时应返回错误")
+	}
+}
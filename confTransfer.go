@@ -0,0 +1,277 @@
+package hh_lol_prophet
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/db/models"
+	"github.com/real-web-world/hh-lol-prophet/services/logger"
+)
+
+const (
+	// 导出文档的当前版本 结构变化时递增并在configDocUpgrades中添加升级函数
+	configDocVersion       = 2
+	confChangeSourceImport = "import"
+)
+
+var (
+	// key为旧版本号 将该版本的文档升级到下一版本
+	// v1: 早期直接导出config/getAll的结果 即不带版本号的ClientUserConf
+	configDocUpgrades = map[int]func(doc map[string]json.RawMessage) (map[string]json.RawMessage, error){
+		1: upgradeConfigDocV1,
+	}
+)
+
+type (
+	// 配置导出文档
+	configDoc struct {
+		SchemaVersion int                    `json:"schemaVersion"`
+		AppVersion    string                 `json:"appVersion"`
+		ExportTime    time.Time              `json:"exportTime"`
+		ClientConf    conf.ClientUserConf    `json:"clientConf"`
+		ActiveProfile *string                `json:"activeProfile"` // 为nil时不修改当前方案 v1文档没有该字段
+		Profiles      []conf.ClientProfile   `json:"profiles"`
+		ChampionRunes []*models.ChampionRune `json:"championRunes"` // 英雄符文及召唤师技能
+		CalcScore     *conf.CalcScoreConf    `json:"calcScore"`     // 计分配置 为空时不修改
+	}
+)
+
+func (p *Prophet) exportConfigDoc() (*configDoc, error) {
+	profiles, err := listClientProfiles()
+	if err != nil {
+		return nil, err
+	}
+	runes, err := models.ChampionRune{}.List()
+	if err != nil {
+		return nil, err
+	}
+	scoreConf := global.GetScoreConf()
+	activeProfile := getActiveProfileName()
+	return &configDoc{
+		SchemaVersion: configDocVersion,
+		AppVersion:    global.AppBuildInfo.Version,
+		ExportTime:    time.Now(),
+		ClientConf:    global.GetClientUserConf(),
+		ActiveProfile: &activeProfile,
+		Profiles:      profiles,
+		ChampionRunes: runes,
+		CalcScore:     &scoreConf,
+	}, nil
+}
+
+// 解析导出文档 旧版本文档逐级升级到当前版本
+func parseConfigDoc(bts []byte) (*configDoc, error) {
+	raw := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(bts, &raw); err != nil {
+		return nil, errors.Wrap(err, "配置文档格式错误")
+	}
+	version := 1
+	if v, ok := raw["schemaVersion"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil {
+			return nil, errors.Wrap(err, "schemaVersion错误")
+		}
+	}
+	if version < 1 || version > configDocVersion {
+		return nil, errors.Errorf("不支持的配置文档版本%d 当前版本为%d", version, configDocVersion)
+	}
+	for ; version < configDocVersion; version++ {
+		var err error
+		if raw, err = configDocUpgrades[version](raw); err != nil {
+			return nil, errors.Wrapf(err, "配置文档从版本%d升级失败", version)
+		}
+	}
+	upgraded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	doc := &configDoc{}
+	if err = json.Unmarshal(upgraded, doc); err != nil {
+		return nil, errors.Wrap(err, "配置文档格式错误")
+	}
+	doc.SchemaVersion = configDocVersion
+	return doc, nil
+}
+func upgradeConfigDocV1(doc map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	clientConf, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]json.RawMessage{
+		"clientConf": clientConf,
+	}, nil
+}
+
+// 导入前整体校验 避免写入一半后失败
+func validConfigDoc(doc *configDoc) error {
+	if errs := conf.CheckClientUserConf(&doc.ClientConf, nil); len(errs) > 0 {
+		return errors.Wrap(errs, "clientConf")
+	}
+	queueProfile := make(map[int]string)
+	names := make([]string, 0, len(doc.Profiles))
+	for _, profile := range doc.Profiles {
+		if err := checkProfileName(profile.Name); err != nil {
+			return err
+		}
+		if slices.Contains(names, profile.Name) {
+			return errors.Errorf("方案%s重复", profile.Name)
+		}
+		names = append(names, profile.Name)
+		if errs := conf.CheckClientUserConf(&profile.Conf, nil); len(errs) > 0 {
+			return errors.Wrap(errs, "方案"+profile.Name)
+		}
+		for _, queueID := range profile.QueueIDList {
+			if name, ok := queueProfile[queueID]; ok {
+				return errors.Errorf("队列%d同时绑定了方案%s和%s", queueID, name, profile.Name)
+			}
+			queueProfile[queueID] = profile.Name
+		}
+	}
+	// 导入的方案会覆盖同名方案 不能与保留的方案冲突
+	existList, err := listClientProfiles()
+	if err != nil {
+		return err
+	}
+	for _, item := range existList {
+		if slices.Contains(names, item.Name) {
+			continue
+		}
+		for _, queueID := range item.QueueIDList {
+			if name, ok := queueProfile[queueID]; ok {
+				return errors.Errorf("队列%d已绑定方案%s 与导入的方案%s冲突", queueID, item.Name, name)
+			}
+		}
+	}
+	if doc.ActiveProfile != nil && *doc.ActiveProfile != "" && !slices.Contains(names, *doc.ActiveProfile) {
+		if _, err = findClientProfile(*doc.ActiveProfile); err != nil {
+			return errors.Wrap(err, "activeProfile")
+		}
+	}
+	for _, item := range doc.ChampionRunes {
+		if item == nil {
+			return errors.New("championRunes中存在空配置")
+		}
+		if err = checkChampionRune(item); err != nil {
+			return errors.Wrapf(err, "英雄%d", item.ChampionID)
+		}
+	}
+	if doc.CalcScore != nil {
+		cfg, err := conf.CloneAppConf(*global.Conf)
+		if err != nil {
+			return err
+		}
+		cfg.CalcScore = *doc.CalcScore
+		if err = conf.ValidAppConf(&cfg); err != nil {
+			return errors.Wrap(err, "calcScore")
+		}
+	}
+	return nil
+}
+
+// 导入配置文档 客户端配置整体替换 方案及符文按名称/英雄位置覆盖 未包含的保留
+// 本地api token 跨域及离线模式属于应用级设置 不随导入修改
+// 数据库写入在同一事务中 提交后才修改内存中的配置及计分配置文件
+func (p *Prophet) importConfigDoc(bts []byte) error {
+	doc, err := parseConfigDoc(bts)
+	if err != nil {
+		return err
+	}
+	if err = validConfigDoc(doc); err != nil {
+		return err
+	}
+	oldCfg, cfg, err := global.SwapClientUserConf(func(cur conf.ClientUserConf) (conf.ClientUserConf, error) {
+		cfg := keepAppLevelClientConf(doc.ClientConf, cur)
+		return cfg, global.SqliteDB.Transaction(func(tx *gorm.DB) error {
+			return writeConfigDoc(tx, doc, cfg)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	changes := diffConfFields("clientConf", oldCfg, cfg)
+	if doc.CalcScore != nil {
+		oldScoreConf := global.GetScoreConf()
+		if err = saveLocalCalcScoreConf(*doc.CalcScore); err != nil {
+			return errors.Wrap(err, "客户端配置已导入 计分配置写入失败")
+		}
+		global.SetScoreConf(*doc.CalcScore)
+		changes = append(changes, diffConfFields("calcScore", oldScoreConf, *doc.CalcScore)...)
+	}
+	logger.Info("已导入配置", zap.Int("profiles", len(doc.Profiles)),
+		zap.Int("championRunes", len(doc.ChampionRunes)), zap.Bool("calcScore", doc.CalcScore != nil))
+	p.emitEvent(EventTypeConfigChanged, ConfigChangedEventData{
+		Source:  confChangeSourceImport,
+		Changes: changes,
+	})
+	return nil
+}
+
+// 写入客户端配置 方案 当前方案及符文
+func writeConfigDoc(tx *gorm.DB, doc *configDoc, cfg conf.ClientUserConf) error {
+	m := models.Config{Tx: tx}
+	bts, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err = m.Update(models.LocalClientConfKey, string(bts)); err != nil {
+		return err
+	}
+	for _, profile := range doc.Profiles {
+		if profile.QueueIDList == nil {
+			profile.QueueIDList = []int{}
+		}
+		if err = putClientProfile(m, profile); err != nil {
+			return err
+		}
+	}
+	switch {
+	case doc.ActiveProfile == nil:
+	case *doc.ActiveProfile == "":
+		err = m.Delete(models.ActiveProfileKey)
+	default:
+		err = m.Save(models.ActiveProfileKey, *doc.ActiveProfile)
+	}
+	if err != nil {
+		return err
+	}
+	runeModel := models.ChampionRune{Tx: tx}
+	for _, item := range doc.ChampionRunes {
+		item.ID = 0
+		if item.SelectedPerkIDs == nil {
+			item.SelectedPerkIDs = []int{}
+		}
+		if err = runeModel.Save(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 计分配置写入本地配置文件的calcScore字段 其他字段保持不变
+func saveLocalCalcScoreConf(scoreConf conf.CalcScoreConf) error {
+	localConf := make(map[string]json.RawMessage)
+	if bts, err := os.ReadFile(conf.LocalConfFilePath); err == nil {
+		if err = json.Unmarshal(bts, &localConf); err != nil {
+			return errors.Wrap(err, "本地配置文件格式错误")
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	scoreBts, err := json.Marshal(scoreConf)
+	if err != nil {
+		return err
+	}
+	localConf["calcScore"] = scoreBts
+	bts, err := json.Marshal(localConf)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(conf.LocalConfFilePath, bts, 0664)
+}
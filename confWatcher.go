@@ -0,0 +1,112 @@
+package hh_lol_prophet
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/logger"
+)
+
+const (
+	confWatchInterval     = time.Second * 2
+	confChangeSourceLocal = "localFile"
+)
+
+var (
+	// 按顺序加载 后加载的覆盖前面的
+	confWatchFiles = []string{conf.EnvFileName, conf.EnvLocalFileName, conf.LocalConfFilePath}
+)
+
+// 轮询本地配置文件的修改时间 变化时重新加载
+// 仅计分配置会热更新 其余配置需重启生效
+func (p *Prophet) watchConfFile() {
+	modTimes := getConfFileModTimes()
+	ticker := time.NewTicker(confWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		currModTimes := getConfFileModTimes()
+		if reflect.DeepEqual(modTimes, currModTimes) {
+			continue
+		}
+		modTimes = currModTimes
+		if err := p.reloadLocalConf(); err != nil {
+			logger.Warn("重新加载本地配置失败,继续使用当前配置", zap.Error(err))
+			p.emitError("重新加载本地配置失败", err)
+		}
+	}
+}
+
+// 不存在的文件记为零值
+func getConfFileModTimes() map[string]time.Time {
+	res := make(map[string]time.Time, len(confWatchFiles))
+	for _, file := range confWatchFiles {
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			res[file] = info.ModTime()
+		}
+	}
+	return res
+}
+func (p *Prophet) reloadLocalConf() error {
+	for _, file := range confWatchFiles[:2] {
+		if _, err := os.Stat(file); err == nil {
+			_ = godotenv.Overload(file)
+		}
+	}
+	newConf, err := conf.LoadLocalAppConf(global.DefaultAppConf)
+	if err != nil {
+		return err
+	}
+	oldScoreConf := global.GetScoreConf()
+	changes := diffConfFields("calcScore", oldScoreConf, newConf.CalcScore)
+	if len(changes) == 0 {
+		return nil
+	}
+	global.SetScoreConf(newConf.CalcScore)
+	logger.Info("计分配置已重新加载", zap.Any("changes", changes))
+	p.emitEvent(EventTypeConfigChanged, ConfigChangedEventData{
+		Source:  confChangeSourceLocal,
+		Changes: changes,
+	})
+	return nil
+}
+
+// 逐字段比较结构体 字段名使用json名称
+func diffConfFields(prefix string, oldCfg, newCfg any) []ConfigFieldDiff {
+	oldVal := reflect.ValueOf(oldCfg)
+	newVal := reflect.ValueOf(newCfg)
+	t := oldVal.Type()
+	changes := make([]ConfigFieldDiff, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		oldField := oldVal.Field(i).Interface()
+		newField := newVal.Field(i).Interface()
+		if reflect.DeepEqual(oldField, newField) {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		changes = append(changes, ConfigFieldDiff{
+			Field: prefix + "." + name,
+			Old:   oldField,
+			New:   newField,
+		})
+	}
+	return changes
+}
@@ -0,0 +1,168 @@
+package hh_lol_prophet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	ginApp "github.com/real-web-world/bdk/gin"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/db/models"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu"
+)
+
+const (
+	diagnosticsMaxLogFiles = 3 // 打包最近的日志文件个数
+	diagnosticsRedacted    = "******"
+)
+
+type (
+	// 诊断包中的运行信息
+	diagnosticsMeta struct {
+		Time             time.Time         `json:"time"`
+		AppInfo          global.AppInfo    `json:"appInfo"`
+		GoVersion        string            `json:"goVersion"`
+		OS               string            `json:"os"`
+		Arch             string            `json:"arch"`
+		SchemaVersion    int               `json:"schemaVersion"`
+		GameState        GameState         `json:"gameState"`
+		GameStateHistory []GameStateRecord `json:"gameStateHistory"` // 从旧到新
+		LcuActive        bool              `json:"lcuActive"`
+		Offline          bool              `json:"offline"`
+		LcuErrors        []lcu.ReqErr      `json:"lcuErrors"` // 从新到旧
+		Errors           []string          `json:"errors"`    // 打包过程中的错误
+	}
+)
+
+// 下载诊断包zip 用于反馈问题
+func (api Api) DownloadDiagnosticsBundle(c *gin.Context) {
+	bts, err := api.p.buildDiagnosticsBundle()
+	if err != nil {
+		ginApp.GetApp(c).CommonError(err)
+		return
+	}
+	fileName := fmt.Sprintf("prophet-diagnostics-%s.zip", time.Now().Format("20060102150405"))
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	c.Data(http.StatusOK, "application/zip", bts)
+}
+
+// 单项失败时记录到meta中 不影响其他内容
+func (p *Prophet) buildDiagnosticsBundle() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	meta := diagnosticsMeta{
+		Time:             time.Now(),
+		AppInfo:          global.AppBuildInfo,
+		GoVersion:        runtime.Version(),
+		OS:               runtime.GOOS,
+		Arch:             runtime.GOARCH,
+		GameState:        p.getGameState(),
+		GameStateHistory: p.fsm.listHistory(),
+		LcuActive:        p.isLcuActive(),
+		Offline:          global.IsOfflineMode(),
+		LcuErrors:        lcu.ListRecentReqErrs(),
+		Errors:           make([]string, 0),
+	}
+	if global.SqliteDB != nil {
+		version, err := models.GetSchemaVersion(global.SqliteDB)
+		if err != nil {
+			meta.Errors = append(meta.Errors, "获取数据库版本失败: "+err.Error())
+		}
+		meta.SchemaVersion = version
+	}
+	if err := writeZipJson(zw, "appConf.json", getRedactedAppConf()); err != nil {
+		return nil, err
+	}
+	if err := writeZipJson(zw, "clientConf.json", global.GetClientUserConf()); err != nil {
+		return nil, err
+	}
+	for _, logPath := range listRecentLogFiles(global.Conf.Log.File.Path, diagnosticsMaxLogFiles) {
+		if err := writeZipFile(zw, "logs/"+filepath.Base(logPath), logPath); err != nil {
+			meta.Errors = append(meta.Errors, "读取日志文件失败: "+err.Error())
+		}
+	}
+	if err := writeZipJson(zw, "meta.json", meta); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 去除配置中的密钥
+func getRedactedAppConf() conf.AppConf {
+	cfg := *global.Conf
+	cfg.CalcScore = global.GetScoreConf()
+	if cfg.Otlp.Token != "" {
+		cfg.Otlp.Token = diagnosticsRedacted
+	}
+	return cfg
+}
+
+// 当前日志文件及滚动后的历史文件 按修改时间从新到旧
+func listRecentLogFiles(logPath string, limit int) []string {
+	if logPath == "" {
+		return nil
+	}
+	ext := filepath.Ext(logPath)
+	prefix := strings.TrimSuffix(logPath, ext)
+	matches, _ := filepath.Glob(prefix + "*" + ext)
+	type logFile struct {
+		path  string
+		mtime time.Time
+	}
+	files := make([]logFile, 0, len(matches))
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, logFile{path: path, mtime: info.ModTime()})
+	}
+	slices.SortFunc(files, func(a, b logFile) int {
+		return b.mtime.Compare(a.mtime)
+	})
+	res := make([]string, 0, limit)
+	for i := 0; i < len(files) && i < limit; i++ {
+		res = append(res, files[i].path)
+	}
+	return res
+}
+func writeZipJson(zw *zip.Writer, name string, data any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+func writeZipFile(zw *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
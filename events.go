@@ -0,0 +1,173 @@
+package hh_lol_prophet
+
+import (
+	"sync"
+	"time"
+
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu"
+)
+
+const (
+	eventSubscriberBufSize = 64               // 单个订阅者缓冲的事件数 消费过慢时丢弃新事件
+	eventHeartbeatInterval = time.Second * 15 // 心跳间隔 防止连接被中间层断开
+)
+
+// eventType
+const (
+	EventTypeLcuConnected    EventType = "lcuConnected"    // 已连接lol客户端
+	EventTypeLcuDisconnected EventType = "lcuDisconnected" // lol客户端已断开
+	EventTypeGameStateChange EventType = "gameStateChange" // 游戏状态变化
+	EventTypePlayerScore     EventType = "playerScore"     // 计算出玩家得分
+	EventTypeAutomation      EventType = "automation"      // 执行了自动化操作
+	EventTypeError           EventType = "error"           // 错误
+	EventTypeConfigChanged   EventType = "configChanged"   // 配置已变更
+)
+
+// automation action
+const (
+	AutomationActionAcceptGame    = "acceptGame"
+	AutomationActionDeclineGame   = "declineGame"
+	AutomationActionPickChampion  = "pickChampion"
+	AutomationActionBanChampion   = "banChampion"
+	AutomationActionSetRune       = "setRune"
+	AutomationActionAramSwap      = "aramSwap"
+	AutomationActionAramReroll    = "aramReroll"
+	AutomationActionHonor         = "honor"
+	AutomationActionSkipHonor     = "skipHonor"
+	AutomationActionPlayAgain     = "playAgain"
+	AutomationActionRequeue       = "requeue"
+	AutomationActionGameReport    = "gameReport"
+	AutomationActionSwitchProfile = "switchProfile"
+)
+
+type (
+	EventType string
+	Event     struct {
+		Type EventType `json:"type"`
+		Time time.Time `json:"time"`
+		Data any       `json:"data"`
+	}
+	GameStateChangeEventData struct {
+		PrevState GameState `json:"prevState"`
+		State     GameState `json:"state"`
+	}
+	PlayerScoreEventData struct {
+		lcu.UserScore
+		Horse   string `json:"horse"`
+		IsEnemy bool   `json:"isEnemy"`
+	}
+	AutomationEventData struct {
+		Action string `json:"action"`
+		Detail any    `json:"detail,omitempty"`
+	}
+	ErrorEventData struct {
+		Msg   string `json:"msg"`
+		Error string `json:"error"`
+	}
+	ConfigChangedEventData struct {
+		Source  string            `json:"source"` // 变更来源 如配置文件
+		Changes []ConfigFieldDiff `json:"changes"`
+	}
+	ConfigFieldDiff struct {
+		Field string `json:"field"`
+		Old   any    `json:"old"`
+		New   any    `json:"new"`
+	}
+	// 事件广播 每个订阅者一个带缓冲的chan
+	eventHub struct {
+		mu   sync.Mutex
+		subs map[chan Event]struct{}
+	}
+)
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		subs: make(map[chan Event]struct{}),
+	}
+}
+
+// 返回事件chan及取消订阅函数 取消后chan会被关闭
+func (h *eventHub) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventSubscriberBufSize)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+func (h *eventHub) publish(evt Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+}
+
+// 向单个订阅者推送 已取消订阅时忽略
+func (h *eventHub) send(events <-chan Event, evt Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		if ch != events {
+			continue
+		}
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+}
+
+// 订阅后先推送当前连接及游戏状态 便于前端初始化
+func (p *Prophet) subscribeEvents() (<-chan Event, func()) {
+	events, unsubscribe := p.events.subscribe()
+	now := time.Now()
+	currState := p.getGameState()
+	if currSummoner := p.getCurrSummoner(); currSummoner != nil {
+		p.events.send(events, Event{Type: EventTypeLcuConnected, Time: now, Data: currSummoner})
+	} else {
+		p.events.send(events, Event{Type: EventTypeLcuDisconnected, Time: now})
+	}
+	p.events.send(events, Event{Type: EventTypeGameStateChange, Time: now,
+		Data: GameStateChangeEventData{State: currState}})
+	return events, unsubscribe
+}
+func (p *Prophet) emitEvent(eventType EventType, data any) {
+	p.events.publish(Event{
+		Type: eventType,
+		Time: time.Now(),
+		Data: data,
+	})
+}
+func (p *Prophet) emitAutomation(action string, detail any) {
+	p.emitEvent(EventTypeAutomation, AutomationEventData{
+		Action: action,
+		Detail: detail,
+	})
+}
+func (p *Prophet) emitError(msg string, err error) {
+	data := ErrorEventData{Msg: msg}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	p.emitEvent(EventTypeError, data)
+}
+func (p *Prophet) emitPlayerScore(score *lcu.UserScore, isEnemy bool) {
+	horse, _ := getHorseByScore(score.Score, global.GetScoreConf(), global.GetClientUserConf())
+	p.emitEvent(EventTypePlayerScore, PlayerScoreEventData{
+		UserScore: *score,
+		Horse:     horse,
+		IsEnemy:   isEnemy,
+	})
+}
@@ -0,0 +1,248 @@
+package hh_lol_prophet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/avast/retry-go"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/db/models"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu"
+	lcuModels "github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+	"github.com/real-web-world/hh-lol-prophet/services/logger"
+)
+
+const (
+	// 结算后战绩可能尚未写入 需等待重试
+	gameReportQueryAttempts = 5
+	gameReportQueryDelay    = time.Second * 3
+)
+
+// 记录赛前计算的得分 用于赛后对比 对局变化时清空之前的得分
+// 选人阶段未取到对局id时先记为0 之后取到的对局id沿用这些得分 选人开始时已清空
+func (p *Prophet) savePredictScores(gameID int64, scores []*lcu.UserScore) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.predictGameID == 0 {
+		p.predictGameID = gameID
+	} else if gameID != 0 && gameID != p.predictGameID {
+		p.predictScores = make(map[int64]float64, 10)
+		p.predictGameID = gameID
+	}
+	for _, score := range scores {
+		p.predictScores[score.SummonerID] = score.Score
+	}
+}
+func (p *Prophet) resetPredictScores() {
+	p.mu.Lock()
+	p.predictScores = make(map[int64]float64, 10)
+	p.predictGameID = 0
+	p.mu.Unlock()
+}
+
+// 仅返回该对局的赛前得分 对局id未知或不一致时返回nil
+func (p *Prophet) getPredictScores(gameID int64) map[int64]float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if gameID == 0 || gameID != p.predictGameID {
+		return nil
+	}
+	scores := make(map[int64]float64, len(p.predictScores))
+	for summonerID, score := range p.predictScores {
+		scores[summonerID] = score
+	}
+	return scores
+}
+
+// 结算后生成赛后报告并保存 按配置复制到剪切板
+func (p *Prophet) onGameReport(ctx context.Context, gameID int64) {
+	var report *models.GameReportData
+	err := retry.Do(func() error {
+		var err error
+		report, err = p.buildGameReport(ctx, gameID, true)
+		return err
+	}, retry.Attempts(gameReportQueryAttempts), retry.Delay(gameReportQueryDelay),
+		retry.DelayType(retry.FixedDelay), retry.LastErrorOnly(true), retry.Context(ctx))
+	if err != nil {
+		logger.Info("生成赛后报告失败", zap.Error(err), zap.Int64("gameID", gameID))
+		p.emitError("生成赛后报告失败", err)
+		return
+	}
+	item := &models.GameReport{GameID: gameID, Data: *report}
+	if err = (models.GameReport{}).Save(item); err != nil {
+		logger.Error("保存赛后报告失败", zap.Error(err), zap.Int64("gameID", gameID))
+	}
+	p.emitAutomation(AutomationActionGameReport, report)
+	if global.GetClientUserConf().GameReportToClipBoard {
+		_ = clipboard.WriteAll(formatGameReport(report))
+		fmt.Println("已将赛后报告复制到剪切板 ", time.Now().Format(time.DateTime))
+	}
+}
+
+// 查询对局详情并计算十名玩家的本局得分 withPredict为true时附带本局的赛前得分
+func (p *Prophet) buildGameReport(ctx context.Context, gameID int64, withPredict bool) (*models.GameReportData, error) {
+	gameSummary, err := p.getLcuClient().QueryGameSummary(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if len(gameSummary.Participants) == 0 {
+		return nil, errors.New("对局详情为空")
+	}
+	var selfID int64
+	if currSummoner := p.getCurrSummoner(); currSummoner != nil {
+		selfID = currSummoner.SummonerId
+	}
+	var predictScores map[int64]float64
+	if withPredict {
+		predictScores = p.getPredictScores(gameID)
+	}
+	scoreCfg := global.GetScoreConf()
+	clientCfg := global.GetClientUserConf()
+	participantIDMapIdentity := make(map[int]lcuModels.ParticipantPlayer, len(gameSummary.ParticipantIdentities))
+	for _, identity := range gameSummary.ParticipantIdentities {
+		participantIDMapIdentity[identity.ParticipantId] = identity.Player
+	}
+	report := &models.GameReportData{
+		GameID:       gameSummary.GameId,
+		QueueID:      gameSummary.QueueId,
+		GameMode:     string(gameSummary.GameMode),
+		GameDuration: gameSummary.GameDuration,
+		GameCreation: gameSummary.GameCreationDate,
+		Players:      make([]models.GameReportPlayer, 0, len(gameSummary.Participants)),
+	}
+	for _, participant := range gameSummary.Participants {
+		player := participantIDMapIdentity[participant.ParticipantId]
+		item := models.GameReportPlayer{
+			SummonerID: player.SummonerId,
+			RiotID:     player.SummonerName,
+			ChampionID: participant.ChampionId,
+			TeamID:     int(participant.TeamId),
+			IsSelf:     selfID != 0 && player.SummonerId == selfID,
+			Win:        participant.Stats.Win,
+			Kills:      participant.Stats.Kills,
+			Deaths:     participant.Stats.Deaths,
+			Assists:    participant.Stats.Assists,
+		}
+		if player.GameName != "" {
+			item.RiotID = fmt.Sprintf("%s#%s", player.GameName, player.TagLine)
+		}
+		if item.Win {
+			report.WinTeamID = item.TeamID
+		}
+		gameScore, err := calcUserGameScore(player.SummonerId, *gameSummary)
+		if err != nil {
+			logger.Debug("计算本局得分失败", zap.Error(err), zap.Int64("summonerID", player.SummonerId))
+		} else {
+			item.Score = gameScore.Value()
+			item.Horse, _ = getHorseByScore(item.Score, scoreCfg, clientCfg)
+		}
+		if predictScore, ok := predictScores[player.SummonerId]; ok {
+			item.PredictScore = &predictScore
+			item.PredictHorse, _ = getHorseByScore(predictScore, scoreCfg, clientCfg)
+		}
+		report.Players = append(report.Players, item)
+	}
+	markCarryAndFeed(report.Players)
+	report.PredictWinTeamID = calcPredictWinTeamID(report.Players)
+	return report, nil
+}
+
+// 每队得分最高的为carry 最低的为feed
+func markCarryAndFeed(players []models.GameReportPlayer) {
+	teamIDMapCarry := make(map[int]int, 2)
+	teamIDMapFeed := make(map[int]int, 2)
+	teamIDMapCount := make(map[int]int, 2)
+	for i, player := range players {
+		teamIDMapCount[player.TeamID]++
+		if idx, ok := teamIDMapCarry[player.TeamID]; !ok || player.Score > players[idx].Score {
+			teamIDMapCarry[player.TeamID] = i
+		}
+		if idx, ok := teamIDMapFeed[player.TeamID]; !ok || player.Score < players[idx].Score {
+			teamIDMapFeed[player.TeamID] = i
+		}
+	}
+	for teamID, count := range teamIDMapCount {
+		if count < 2 {
+			continue
+		}
+		players[teamIDMapCarry[teamID]].IsCarry = true
+		players[teamIDMapFeed[teamID]].IsFeed = true
+	}
+}
+
+// 双方都有赛前得分时 平均分高的一方为预测胜方
+func calcPredictWinTeamID(players []models.GameReportPlayer) int {
+	teamIDMapTotal := make(map[int]float64, 2)
+	teamIDMapCount := make(map[int]int, 2)
+	for _, player := range players {
+		if player.PredictScore == nil {
+			continue
+		}
+		teamIDMapTotal[player.TeamID] += *player.PredictScore
+		teamIDMapCount[player.TeamID]++
+	}
+	if len(teamIDMapCount) != 2 {
+		return 0
+	}
+	winTeamID, maxAvg := 0, 0.0
+	for teamID, total := range teamIDMapTotal {
+		avg := total / float64(teamIDMapCount[teamID])
+		if winTeamID == 0 || avg > maxAvg {
+			winTeamID, maxAvg = teamID, avg
+		} else if avg == maxAvg {
+			winTeamID = 0
+		}
+	}
+	return winTeamID
+}
+
+func getTeamName(teamID int) string {
+	switch lcuModels.TeamID(teamID) {
+	case lcuModels.TeamIDBlue:
+		return "蓝色方"
+	case lcuModels.TeamIDRed:
+		return "红色方"
+	default:
+		return "未知"
+	}
+}
+
+func formatGameReport(report *models.GameReportData) string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("赛后报告 对局:%d 时长:%d分%d秒\n", report.GameID,
+		report.GameDuration/60, report.GameDuration%60))
+	if report.PredictWinTeamID != 0 {
+		result := "预测失败"
+		if report.PredictWinTeamID == report.WinTeamID {
+			result = "预测正确"
+		}
+		sb.WriteString(fmt.Sprintf("预测胜方:%s 实际胜方:%s %s\n", getTeamName(report.PredictWinTeamID),
+			getTeamName(report.WinTeamID), result))
+	}
+	for _, teamID := range []lcuModels.TeamID{lcuModels.TeamIDBlue, lcuModels.TeamIDRed} {
+		for _, player := range report.Players {
+			if player.TeamID != int(teamID) {
+				continue
+			}
+			tag := ""
+			if player.IsCarry {
+				tag = "[carry]"
+			} else if player.IsFeed {
+				tag = "[feed]"
+			}
+			line := fmt.Sprintf("%s %s%s(%d) %s %d-%d-%d", getTeamName(player.TeamID), tag, player.Horse,
+				int(player.Score), player.RiotID, player.Kills, player.Deaths, player.Assists)
+			if player.PredictScore != nil {
+				line += fmt.Sprintf(" 赛前:%s(%d)", player.PredictHorse, int(*player.PredictScore))
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,26 @@
+package hh_lol_prophet
+
+import (
+	"testing"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu"
+)
+
+// 选人阶段未取到对局id时 队友得分在取到对局id后仍保留
+func TestSavePredictScores(t *testing.T) {
+	p, _ := newTestProphet(t, func(*conf.ClientUserConf) {})
+	p.savePredictScores(0, []*lcu.UserScore{{SummonerID: 1, Score: 100}})
+	p.savePredictScores(123, []*lcu.UserScore{{SummonerID: 2, Score: 90}})
+	if scores := p.getPredictScores(123); len(scores) != 2 {
+		t.Fatalf("赛前得分丢失: %v", scores)
+	}
+	// 对局变化时清空之前的得分
+	p.savePredictScores(456, []*lcu.UserScore{{SummonerID: 3, Score: 80}})
+	if scores := p.getPredictScores(456); len(scores) != 1 {
+		t.Fatalf("未清空之前对局的得分: %v", scores)
+	}
+	if scores := p.getPredictScores(123); scores != nil {
+		t.Fatalf("不应返回其他对局的得分: %v", scores)
+	}
+}
@@ -0,0 +1,261 @@
+package hh_lol_prophet
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+	"github.com/real-web-world/hh-lol-prophet/services/logger"
+	"github.com/real-web-world/hh-lol-prophet/services/metrics"
+)
+
+// gameState 已有状态的值保持不变 兼容前端
+const (
+	GameStateNone                  GameState = "none"
+	GameStateLobby                 GameState = "lobby"
+	GameStateMatchmaking           GameState = "Matchmaking"
+	GameStateReadyCheck            GameState = "ReadyCheck"
+	GameStateChampSelect           GameState = "champSelect"
+	GameStateCheckedIntoTournament GameState = "checkedIntoTournament"
+	GameStateGameStart             GameState = "gameStart"
+	GameStateFailedToLaunch        GameState = "failedToLaunch"
+	GameStateInGame                GameState = "inGame"
+	GameStateReconnect             GameState = "reconnect"
+	GameStateTerminatedInError     GameState = "terminatedInError"
+	GameStateWaitingForStats       GameState = "waitingForStats"
+	GameStatePreEndOfGame          GameState = "preEndOfGame"
+	GameStateEndOfGame             GameState = "endOfGame"
+	GameStateOther                 GameState = "other" // 未知的游戏阶段
+)
+
+const (
+	gameStateHistorySize = 20
+)
+
+var (
+	gameFlowStateMap = map[models.GameFlow]GameState{
+		models.GameFlowNone:                  GameStateNone,
+		models.GameFlowLobby:                 GameStateLobby,
+		models.GameFlowMatchmaking:           GameStateMatchmaking,
+		models.GameFlowReadyCheck:            GameStateReadyCheck,
+		models.GameFlowChampionSelect:        GameStateChampSelect,
+		models.GameFlowCheckedIntoTournament: GameStateCheckedIntoTournament,
+		models.GameFlowGameStart:             GameStateGameStart,
+		models.GameFlowFailedToLaunch:        GameStateFailedToLaunch,
+		models.GameFlowInProgress:            GameStateInGame,
+		models.GameFlowReconnect:             GameStateReconnect,
+		models.GameFlowTerminatedInError:     GameStateTerminatedInError,
+		models.GameFlowWaitingForStats:       GameStateWaitingForStats,
+		models.GameFlowPreEndOfGame:          GameStatePreEndOfGame,
+		models.GameFlowEndOfGame:             GameStateEndOfGame,
+	}
+	// 合法的状态转换 切换到none及进出other总是合法
+	// 启动时客户端已在对局中或漏掉事件时会出现其他转换 仍然切换并记录警告
+	gameStateTransitions = map[GameState][]GameState{
+		GameStateNone: {GameStateLobby, GameStateReconnect, GameStateCheckedIntoTournament},
+		GameStateLobby: {GameStateMatchmaking, GameStateChampSelect, GameStateCheckedIntoTournament,
+			GameStateGameStart},
+		GameStateMatchmaking:           {GameStateReadyCheck, GameStateLobby},
+		GameStateReadyCheck:            {GameStateChampSelect, GameStateMatchmaking, GameStateLobby},
+		GameStateChampSelect:           {GameStateGameStart, GameStateInGame, GameStateLobby, GameStateMatchmaking},
+		GameStateCheckedIntoTournament: {GameStateChampSelect, GameStateLobby},
+		GameStateGameStart:             {GameStateInGame, GameStateFailedToLaunch, GameStateReconnect},
+		GameStateFailedToLaunch:        {GameStateGameStart, GameStateReconnect, GameStateLobby},
+		GameStateInGame: {GameStateWaitingForStats, GameStatePreEndOfGame, GameStateEndOfGame,
+			GameStateReconnect, GameStateTerminatedInError},
+		GameStateReconnect: {GameStateInGame, GameStateWaitingForStats, GameStatePreEndOfGame,
+			GameStateEndOfGame},
+		GameStateTerminatedInError: {GameStateReconnect, GameStateEndOfGame, GameStateLobby},
+		GameStateWaitingForStats:   {GameStatePreEndOfGame, GameStateEndOfGame},
+		GameStatePreEndOfGame:      {GameStateEndOfGame},
+		GameStateEndOfGame:         {GameStateLobby, GameStateMatchmaking},
+	}
+)
+
+type (
+	// ctx在离开该状态时取消 钩子在状态切换的goroutine中同步执行 耗时操作需自行开启goroutine
+	GameStateHook       func(ctx context.Context, t GameStateTransition)
+	GameStateTransition struct {
+		From  GameState `json:"from"`
+		To    GameState `json:"to"`
+		Time  time.Time `json:"time"`
+		Legal bool      `json:"legal"`
+	}
+	// exitedAt为空表示当前状态
+	GameStateRecord struct {
+		State     GameState  `json:"state"`
+		EnteredAt time.Time  `json:"enteredAt"`
+		ExitedAt  *time.Time `json:"exitedAt"`
+	}
+	gameStateMachine struct {
+		mu         sync.Mutex
+		state      GameState
+		enteredAt  time.Time
+		ctx        context.Context // 当前状态的ctx
+		cancel     context.CancelFunc
+		history    []GameStateRecord // 从旧到新 最后一条为当前状态
+		enterHooks map[GameState][]GameStateHook
+		exitHooks  map[GameState][]GameStateHook
+	}
+)
+
+func newGameStateMachine(ctx context.Context) *gameStateMachine {
+	now := time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	return &gameStateMachine{
+		state:      GameStateNone,
+		enteredAt:  now,
+		ctx:        ctx,
+		cancel:     cancel,
+		history:    []GameStateRecord{{State: GameStateNone, EnteredAt: now}},
+		enterHooks: make(map[GameState][]GameStateHook),
+		exitHooks:  make(map[GameState][]GameStateHook),
+	}
+}
+func isLegalGameStateTransition(from, to GameState) bool {
+	if to == GameStateNone || from == GameStateOther || to == GameStateOther {
+		return true
+	}
+	return slices.Contains(gameStateTransitions[from], to)
+}
+func (m *gameStateMachine) onEnter(state GameState, hook GameStateHook) {
+	m.mu.Lock()
+	m.enterHooks[state] = append(m.enterHooks[state], hook)
+	m.mu.Unlock()
+}
+func (m *gameStateMachine) onExit(state GameState, hook GameStateHook) {
+	m.mu.Lock()
+	m.exitHooks[state] = append(m.exitHooks[state], hook)
+	m.mu.Unlock()
+}
+
+// 切换状态并取消上一状态的ctx 状态未变化时changed为false
+func (m *gameStateMachine) transit(parent context.Context, to GameState) (t GameStateTransition,
+	ctx context.Context, changed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state == to {
+		return t, nil, false
+	}
+	now := time.Now()
+	t = GameStateTransition{
+		From:  m.state,
+		To:    to,
+		Time:  now,
+		Legal: isLegalGameStateTransition(m.state, to),
+	}
+	m.cancel()
+	ctx, m.cancel = context.WithCancel(parent)
+	m.ctx = ctx
+	m.history[len(m.history)-1].ExitedAt = &now
+	m.history = append(m.history, GameStateRecord{State: to, EnteredAt: now})
+	if len(m.history) > gameStateHistorySize {
+		m.history = slices.Clone(m.history[len(m.history)-gameStateHistorySize:])
+	}
+	m.state = to
+	m.enteredAt = now
+	return t, ctx, true
+}
+
+// 先执行上一状态的exit钩子 再执行新状态的enter钩子 两者的ctx均为新状态的ctx
+func (m *gameStateMachine) runHooks(ctx context.Context, t GameStateTransition) {
+	m.mu.Lock()
+	exitHooks := slices.Clone(m.exitHooks[t.From])
+	enterHooks := slices.Clone(m.enterHooks[t.To])
+	m.mu.Unlock()
+	for _, hook := range exitHooks {
+		hook(ctx, t)
+	}
+	for _, hook := range enterHooks {
+		hook(ctx, t)
+	}
+}
+func (m *gameStateMachine) current() (GameState, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state, m.enteredAt
+}
+func (m *gameStateMachine) currentCtx() (GameState, context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state, m.ctx
+}
+func (m *gameStateMachine) listHistory() []GameStateRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.history)
+}
+
+// 注册进入状态时的钩子
+func (p *Prophet) OnGameStateEnter(state GameState, hook GameStateHook) {
+	p.fsm.onEnter(state, hook)
+}
+
+// 注册离开状态时的钩子
+func (p *Prophet) OnGameStateExit(state GameState, hook GameStateHook) {
+	p.fsm.onExit(state, hook)
+}
+func (p *Prophet) onGameFlowUpdate(gameFlow models.GameFlow) {
+	logger.Debug("切换状态:" + string(gameFlow))
+	state, ok := gameFlowStateMap[gameFlow]
+	if !ok {
+		state = GameStateOther
+	}
+	p.transitGameState(state, gameFlow)
+}
+func (p *Prophet) transitGameState(state GameState, gameFlow models.GameFlow) {
+	t, ctx, changed := p.fsm.transit(p.ctx, state)
+	if !changed {
+		return
+	}
+	if gameFlow != "" {
+		ctx = p.startGameFlowSpan(ctx, gameFlow)
+	} else {
+		p.endGameFlowSpan()
+	}
+	if !t.Legal {
+		logger.Warn("非预期的游戏状态切换", zap.String("from", string(t.From)), zap.String("to", string(t.To)),
+			zap.String("gameFlow", string(gameFlow)))
+	}
+	metrics.IncGameStateTransition(string(t.From), string(t.To))
+	p.emitEvent(EventTypeGameStateChange, GameStateChangeEventData{
+		PrevState: t.From,
+		State:     t.To,
+	})
+	p.fsm.runHooks(ctx, t)
+}
+func (p *Prophet) getGameState() GameState {
+	state, _ := p.fsm.current()
+	return state
+}
+
+// 各阶段的自动化操作
+func (p *Prophet) registerGameStateHooks() {
+	p.OnGameStateEnter(GameStateChampSelect, func(ctx context.Context, _ GameStateTransition) {
+		logger.Info("进入英雄选择阶段,正在计算用户分数")
+		p.resetRuneApplied()
+		p.resetPredictScores()
+		p.resetCurrentMatch()
+		go p.ChampionSelectStart(ctx)
+	})
+	p.OnGameStateExit(GameStateChampSelect, func(context.Context, GameStateTransition) {
+		p.stopChatQueues()
+	})
+	p.OnGameStateEnter(GameStateReadyCheck, func(ctx context.Context, _ GameStateTransition) {
+		go p.onReadyCheck(ctx)
+	})
+	p.OnGameStateEnter(GameStateInGame, func(ctx context.Context, _ GameStateTransition) {
+		go p.CalcEnemyTeamScore(ctx)
+	})
+	p.OnGameStateEnter(GameStatePreEndOfGame, func(ctx context.Context, _ GameStateTransition) {
+		go p.onPreEndOfGame(ctx)
+	})
+	// 返回房间后才会自动匹配 不随结算阶段取消 客户端断开或退出时取消
+	p.OnGameStateEnter(GameStateEndOfGame, func(context.Context, GameStateTransition) {
+		go p.onEndOfGame(p.getLcuCtx())
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"log"
 	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -29,13 +31,16 @@ type (
 
 // envKey
 const (
-	EnvKeyMode = "PROPHET_MODE"
+	EnvKeyMode    = "PROPHET_MODE"
+	EnvKeyOffline = "PROPHET_OFFLINE"
 )
 
 const (
 	ZapLoggerCleanupKey = "ZapLogger"
 	LogWriterCleanupKey = "logWriter"
+	LogFileCleanupKey   = "logFile"
 	OtelCleanupKey      = "otel"
+	OtelTraceCleanupKey = "otelTrace"
 )
 
 var (
@@ -52,6 +57,28 @@ var (
 		ChooseChampSendMsgDelaySec:     3,
 		ShouldInGameSaveMsgToClipBoard: true,
 		ShouldAutoOpenBrowser:          &defaultShouldAutoOpenBrowserCfg,
+		AutoSetRuneAndSpell:            false,
+		AramAutoSwap:                   false,
+		AramChampPriority:              []int{},
+		AramAutoReroll:                 false,
+		AramSwapDelayMs:                1500,
+		AutoAcceptDelaySec:             [2]int{0, 0},
+		AutoAcceptQueueIDList:          []int{},
+		AutoAcceptOnlyFullLobby:        false,
+		AutoDeclineQueueIDList:         []int{},
+		AutoSkipHonor:                  false,
+		AutoHonorRiotIDList:            []string{},
+		AutoPlayAgain:                  false,
+		AutoRequeue:                    false,
+		AutoRequeueDelaySec:            3,
+		AutoRequeueStopAfterLosses:     0,
+		TeamHorseMsgTpl:                conf.DefaultTeamHorseMsgTpl,
+		MergedHorseMsgTpl:              conf.DefaultMergedHorseMsgTpl,
+		EnemyHorseMsgTpl:               conf.DefaultEnemyHorseMsgTpl,
+		GameReportToClipBoard:          false,
+		LocalApiTokenEnabled:           false,
+		AllowOriginList:                conf.DefaultAllowOriginList,
+		OfflineMode:                    false,
 	}
 	DefaultAppConf = conf.AppConf{
 		CalcScore: conf.CalcScoreConf{
@@ -137,6 +164,8 @@ var (
 		},
 	}
 	userInfo       = &UserInfo{}
+	localApiToken  string
+	offlineMode    atomic.Bool
 	confMu         = sync.Mutex{}
 	Conf           = new(conf.AppConf)
 	ClientUserConf = new(conf.ClientUserConf)
@@ -160,6 +189,28 @@ func SetCurrSummoner(summoner *models.SummonerProfileData) {
 	userInfo.Summoner = summoner
 	confMu.Unlock()
 }
+func SetLocalApiToken(token string) {
+	confMu.Lock()
+	localApiToken = token
+	confMu.Unlock()
+}
+func GetLocalApiToken() string {
+	confMu.Lock()
+	defer confMu.Unlock()
+	return localApiToken
+}
+
+// 启动时确定 运行中不会变化
+func SetOfflineMode(offline bool) {
+	offlineMode.Store(offline)
+}
+func IsOfflineMode() bool {
+	return offlineMode.Load()
+}
+func IsEnvOffline() bool {
+	offline, _ := strconv.ParseBool(os.Getenv(EnvKeyOffline))
+	return offline
+}
 func GetUserInfo() UserInfo {
 	confMu.Lock()
 	defer confMu.Unlock()
@@ -214,40 +265,132 @@ func GetClientUserConf() conf.ClientUserConf {
 	data := *ClientUserConf
 	return data
 }
-func SetClientUserConf(cfg conf.UpdateClientUserConfReq) *conf.ClientUserConf {
+
+// 由fn根据当前配置生成新配置并持久化 fn返回错误时不修改内存中的配置
+// fn执行期间持有配置锁 不能在fn中获取客户端配置
+func SwapClientUserConf(fn func(cur conf.ClientUserConf) (conf.ClientUserConf, error)) (old, cfg conf.ClientUserConf, err error) {
 	confMu.Lock()
 	defer confMu.Unlock()
+	old = *ClientUserConf
+	if cfg, err = fn(old); err != nil {
+		return old, old, err
+	}
+	*ClientUserConf = cfg
+	return old, cfg, nil
+}
+
+// 在当前配置上应用修改 fn用于校验及持久化 返回错误时不修改内存中的配置
+// fn执行期间持有配置锁 不能在fn中获取客户端配置
+func UpdateClientUserConf(cfg conf.UpdateClientUserConfReq, fn func(cfg conf.ClientUserConf) error) (*conf.ClientUserConf, error) {
+	_, merged, err := SwapClientUserConf(func(cur conf.ClientUserConf) (conf.ClientUserConf, error) {
+		merged := mergeClientUserConf(cur, cfg)
+		return merged, fn(merged)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &merged, nil
+}
+
+// 将修改应用到dst的副本上
+func mergeClientUserConf(dst conf.ClientUserConf, cfg conf.UpdateClientUserConfReq) conf.ClientUserConf {
 	if cfg.AutoAcceptGame != nil {
-		ClientUserConf.AutoAcceptGame = *cfg.AutoAcceptGame
+		dst.AutoAcceptGame = *cfg.AutoAcceptGame
 	}
 	if cfg.AutoPickChampID != nil {
-		ClientUserConf.AutoPickChampID = *cfg.AutoPickChampID
+		dst.AutoPickChampID = *cfg.AutoPickChampID
 	}
 	if cfg.AutoBanChampID != nil {
-		ClientUserConf.AutoBanChampID = *cfg.AutoBanChampID
+		dst.AutoBanChampID = *cfg.AutoBanChampID
 	}
 	if cfg.AutoSendTeamHorse != nil {
-		ClientUserConf.AutoSendTeamHorse = *cfg.AutoSendTeamHorse
+		dst.AutoSendTeamHorse = *cfg.AutoSendTeamHorse
 	}
 	if cfg.ShouldSendSelfHorse != nil {
-		ClientUserConf.ShouldSendSelfHorse = *cfg.ShouldSendSelfHorse
+		dst.ShouldSendSelfHorse = *cfg.ShouldSendSelfHorse
 	}
 	if cfg.HorseNameConf != nil {
-		ClientUserConf.HorseNameConf = *cfg.HorseNameConf
+		dst.HorseNameConf = *cfg.HorseNameConf
 	}
 	if cfg.ChooseSendHorseMsg != nil {
-		ClientUserConf.ChooseSendHorseMsg = *cfg.ChooseSendHorseMsg
+		dst.ChooseSendHorseMsg = *cfg.ChooseSendHorseMsg
 	}
 	if cfg.ChooseChampSendMsgDelaySec != nil {
-		ClientUserConf.ChooseChampSendMsgDelaySec = *cfg.ChooseChampSendMsgDelaySec
+		dst.ChooseChampSendMsgDelaySec = *cfg.ChooseChampSendMsgDelaySec
 	}
 	if cfg.ShouldInGameSaveMsgToClipBoard != nil {
-		ClientUserConf.ShouldInGameSaveMsgToClipBoard = *cfg.ShouldInGameSaveMsgToClipBoard
+		dst.ShouldInGameSaveMsgToClipBoard = *cfg.ShouldInGameSaveMsgToClipBoard
 	}
 	if cfg.ShouldAutoOpenBrowser != nil {
-		ClientUserConf.ShouldAutoOpenBrowser = cfg.ShouldAutoOpenBrowser
+		dst.ShouldAutoOpenBrowser = cfg.ShouldAutoOpenBrowser
+	}
+	if cfg.AutoSetRuneAndSpell != nil {
+		dst.AutoSetRuneAndSpell = *cfg.AutoSetRuneAndSpell
+	}
+	if cfg.AramAutoSwap != nil {
+		dst.AramAutoSwap = *cfg.AramAutoSwap
+	}
+	if cfg.AramChampPriority != nil {
+		dst.AramChampPriority = *cfg.AramChampPriority
+	}
+	if cfg.AramAutoReroll != nil {
+		dst.AramAutoReroll = *cfg.AramAutoReroll
+	}
+	if cfg.AramSwapDelayMs != nil {
+		dst.AramSwapDelayMs = *cfg.AramSwapDelayMs
+	}
+	if cfg.AutoAcceptDelaySec != nil {
+		dst.AutoAcceptDelaySec = *cfg.AutoAcceptDelaySec
+	}
+	if cfg.AutoAcceptQueueIDList != nil {
+		dst.AutoAcceptQueueIDList = *cfg.AutoAcceptQueueIDList
+	}
+	if cfg.AutoAcceptOnlyFullLobby != nil {
+		dst.AutoAcceptOnlyFullLobby = *cfg.AutoAcceptOnlyFullLobby
+	}
+	if cfg.AutoDeclineQueueIDList != nil {
+		dst.AutoDeclineQueueIDList = *cfg.AutoDeclineQueueIDList
+	}
+	if cfg.AutoSkipHonor != nil {
+		dst.AutoSkipHonor = *cfg.AutoSkipHonor
+	}
+	if cfg.AutoHonorRiotIDList != nil {
+		dst.AutoHonorRiotIDList = *cfg.AutoHonorRiotIDList
+	}
+	if cfg.AutoPlayAgain != nil {
+		dst.AutoPlayAgain = *cfg.AutoPlayAgain
+	}
+	if cfg.AutoRequeue != nil {
+		dst.AutoRequeue = *cfg.AutoRequeue
+	}
+	if cfg.AutoRequeueDelaySec != nil {
+		dst.AutoRequeueDelaySec = *cfg.AutoRequeueDelaySec
+	}
+	if cfg.AutoRequeueStopAfterLosses != nil {
+		dst.AutoRequeueStopAfterLosses = *cfg.AutoRequeueStopAfterLosses
+	}
+	if cfg.TeamHorseMsgTpl != nil {
+		dst.TeamHorseMsgTpl = *cfg.TeamHorseMsgTpl
+	}
+	if cfg.MergedHorseMsgTpl != nil {
+		dst.MergedHorseMsgTpl = *cfg.MergedHorseMsgTpl
+	}
+	if cfg.EnemyHorseMsgTpl != nil {
+		dst.EnemyHorseMsgTpl = *cfg.EnemyHorseMsgTpl
+	}
+	if cfg.GameReportToClipBoard != nil {
+		dst.GameReportToClipBoard = *cfg.GameReportToClipBoard
+	}
+	if cfg.LocalApiTokenEnabled != nil {
+		dst.LocalApiTokenEnabled = *cfg.LocalApiTokenEnabled
+	}
+	if cfg.AllowOriginList != nil {
+		dst.AllowOriginList = *cfg.AllowOriginList
+	}
+	if cfg.OfflineMode != nil {
+		dst.OfflineMode = *cfg.OfflineMode
 	}
-	return ClientUserConf
+	return dst
 }
 func SetAppInfo(info AppInfo) {
 	AppBuildInfo = info
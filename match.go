@@ -0,0 +1,143 @@
+package hh_lol_prophet
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+)
+
+type (
+	// 当前对局双方的得分信息
+	CurrentMatch struct {
+		GameState GameState      `json:"gameState"`
+		UpdatedAt time.Time      `json:"updatedAt"`
+		Team      []horseMsgData `json:"team"`  // 我方 按得分从高到低
+		Enemy     []horseMsgData `json:"enemy"` // 敌方 进入游戏后才有
+	}
+)
+
+func (p *Prophet) resetCurrentMatch() {
+	p.mu.Lock()
+	p.currMatch = CurrentMatch{UpdatedAt: time.Now()}
+	p.mu.Unlock()
+}
+func (p *Prophet) setCurrentMatchTeam(list []horseMsgData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	championIDMap := getChampionIDMapFromMatchPlayers(p.currMatch.Team)
+	p.currMatch.Team = slices.Clone(list)
+	fillMatchPlayersChampion(p.currMatch.Team, championIDMap)
+	p.currMatch.UpdatedAt = time.Now()
+}
+func (p *Prophet) setCurrentMatchEnemy(list []horseMsgData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.currMatch.Enemy = slices.Clone(list)
+	p.currMatch.UpdatedAt = time.Now()
+}
+
+// 选人阶段及进入游戏后同步英雄
+func (p *Prophet) updateCurrentMatchChampions(championIDMap map[int64]int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fillMatchPlayersChampion(p.currMatch.Team, championIDMap)
+	fillMatchPlayersChampion(p.currMatch.Enemy, championIDMap)
+}
+func (p *Prophet) getCurrentMatch() CurrentMatch {
+	gameState := p.getGameState()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return CurrentMatch{
+		GameState: gameState,
+		UpdatedAt: p.currMatch.UpdatedAt,
+		Team:      slices.Clone(p.currMatch.Team),
+		Enemy:     slices.Clone(p.currMatch.Enemy),
+	}
+}
+
+// 根据当前游戏阶段重新计算双方得分 不发送消息
+func (p *Prophet) refreshCurrentMatch(ctx context.Context) (CurrentMatch, error) {
+	ctx, span := tracer.Start(ctx, "refreshCurrentMatch")
+	defer span.End()
+	cli := p.getLcuClient()
+	session, err := cli.QueryGameFlowSession(ctx)
+	if err != nil {
+		return CurrentMatch{}, err
+	}
+	currSummoner := p.getCurrSummoner()
+	if currSummoner == nil {
+		return CurrentMatch{}, errors.New("获取当前召唤师信息失败")
+	}
+	selfID := currSummoner.SummonerId
+	switch session.Phase {
+	case models.GameFlowChampionSelect:
+		_, summonerIDList, err := getTeamUsers(ctx, cli)
+		if err != nil {
+			return CurrentMatch{}, err
+		}
+		summonerScores, err := p.calcSummonerScores(ctx, session.GameData.GameId, summonerIDList, false)
+		if err != nil {
+			return CurrentMatch{}, err
+		}
+		p.setCurrentMatchTeam(newMatchPlayers(summonerScores, selfID, false))
+		if sessionInfo, err := cli.GetChampSelectSession(ctx); err == nil {
+			p.updateCurrentMatchChampions(getChampionIDMapFromChampSelect(sessionInfo))
+		}
+	case models.GameFlowInProgress:
+		selfTeamUsers, enemyTeamUsers := getAllUsersFromSession(selfID, session)
+		teamScores, err := p.calcSummonerScores(ctx, session.GameData.GameId, selfTeamUsers, false)
+		if err != nil {
+			return CurrentMatch{}, err
+		}
+		enemyScores, err := p.calcSummonerScores(ctx, session.GameData.GameId, enemyTeamUsers, true)
+		if err != nil {
+			return CurrentMatch{}, err
+		}
+		p.setCurrentMatchTeam(newMatchPlayers(teamScores, selfID, false))
+		p.setCurrentMatchEnemy(newMatchPlayers(enemyScores, selfID, true))
+		p.updateCurrentMatchChampions(getChampionIDMapFromSession(session))
+	default:
+		return CurrentMatch{}, errors.New("当前不在选人或游戏阶段")
+	}
+	return p.getCurrentMatch(), nil
+}
+
+func newMatchPlayers(summonerScores []*lcu.UserScore, selfID int64, isEnemy bool) []horseMsgData {
+	scoreCfg := global.GetScoreConf()
+	clientCfg := global.GetClientUserConf()
+	list := make([]horseMsgData, 0, len(summonerScores))
+	for _, scoreInfo := range summonerScores {
+		msgData := newHorseMsgData(scoreInfo, scoreCfg, clientCfg, selfID)
+		msgData.IsEnemy = isEnemy
+		list = append(list, msgData)
+	}
+	return list
+}
+func fillMatchPlayersChampion(list []horseMsgData, championIDMap map[int64]int) {
+	for i := range list {
+		if championID, ok := championIDMap[list[i].SummonerID]; ok && championID > 0 {
+			list[i].ChampionID = championID
+			list[i].ChampionName = getCachedChampionName(championID)
+		}
+	}
+}
+func getChampionIDMapFromMatchPlayers(list []horseMsgData) map[int64]int {
+	res := make(map[int64]int, len(list))
+	for _, item := range list {
+		res[item.SummonerID] = item.ChampionID
+	}
+	return res
+}
+func getChampionIDMapFromChampSelect(sessionInfo *models.ChampSelectSessionInfo) map[int64]int {
+	res := make(map[int64]int, len(sessionInfo.MyTeam))
+	for _, member := range sessionInfo.MyTeam {
+		res[member.SummonerId] = member.ChampionId
+	}
+	return res
+}
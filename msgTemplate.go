@@ -0,0 +1,169 @@
+package hh_lol_prophet
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu"
+)
+
+// 消息模板类型
+const (
+	msgTplTypeTeam   = "team"
+	msgTplTypeMerged = "merged"
+	msgTplTypeEnemy  = "enemy"
+	noGameHistory    = "未查询到战绩"
+)
+
+type (
+	// 单个玩家的模板数据
+	horseMsgData struct {
+		Horse        string   `json:"horse"`        // 马匹名称 无战绩时为"未查询到战绩"
+		HorseIdx     int      `json:"horseIdx"`     // 马匹等级 0为最高
+		Score        int      `json:"score"`        // 得分
+		RawScore     float64  `json:"rawScore"`     // 原始得分
+		RiotID       string   `json:"riotID"`       // gameName#tagLine
+		SummonerID   int64    `json:"summonerID"`   // 召唤师id
+		KDAList      [][3]int `json:"kdaList"`      // 最近对局kda [击杀,死亡,助攻]
+		KDA          string   `json:"kda"`          // 最近5局kda 例如 "1-2-3  4-5-6"
+		ChampionID   int      `json:"championID"`   // 英雄id 未知时为0
+		ChampionName string   `json:"championName"` // 英雄名称 未知时为空
+		IsSelf       bool     `json:"isSelf"`       // 是否为自己
+		IsEnemy      bool     `json:"isEnemy"`      // 是否为敌方
+		HasHistory   bool     `json:"hasHistory"`   // 是否查询到战绩
+		WebsiteTitle string   `json:"-"`            // 网站名称
+	}
+	// 合并消息/敌方信息的模板数据
+	horseMsgListData struct {
+		Players      []horseMsgData
+		WebsiteTitle string
+	}
+)
+
+func newHorseMsgData(scoreInfo *lcu.UserScore, scoreCfg conf.CalcScoreConf, clientCfg conf.ClientUserConf,
+	selfID int64) horseMsgData {
+	data := horseMsgData{
+		Score:        int(scoreInfo.Score),
+		RawScore:     scoreInfo.Score,
+		RiotID:       scoreInfo.SummonerName,
+		SummonerID:   scoreInfo.SummonerID,
+		KDAList:      scoreInfo.CurrKDA,
+		IsSelf:       selfID != 0 && scoreInfo.SummonerID == selfID,
+		HasHistory:   len(scoreInfo.CurrKDA) > 0,
+		WebsiteTitle: global.Conf.AdaptChatWebsiteTitle,
+	}
+	data.Horse, data.HorseIdx = getHorseByScore(scoreInfo.Score, scoreCfg, clientCfg)
+	kdaList := make([]string, 0, 5)
+	for i := 0; i < 5 && i < len(scoreInfo.CurrKDA); i++ {
+		kda := scoreInfo.CurrKDA[i]
+		kdaList = append(kdaList, fmt.Sprintf("%d-%d-%d", kda[0], kda[1], kda[2]))
+	}
+	data.KDA = strings.Join(kdaList, "  ")
+	return data
+}
+
+// 根据得分返回马匹名称及其在配置中的下标
+func getHorseByScore(score float64, scoreCfg conf.CalcScoreConf, clientCfg conf.ClientUserConf) (string, int) {
+	for i, v := range scoreCfg.Horse {
+		if score >= v.Score {
+			return clientCfg.HorseNameConf[i], i
+		}
+	}
+	return "", 0
+}
+
+func renderMsgTpl(tpl, defaultTpl string, data any) (string, error) {
+	if tpl == "" {
+		tpl = defaultTpl
+	}
+	t, err := template.New("").Parse(tpl)
+	if err != nil {
+		return "", err
+	}
+	sb := strings.Builder{}
+	if err = t.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// 模板错误时回退到默认模板 保证消息正常发送
+func renderMsgTplOrDefault(tpl, defaultTpl string, data any) string {
+	msg, err := renderMsgTpl(tpl, defaultTpl, data)
+	if err != nil {
+		msg, _ = renderMsgTpl(defaultTpl, defaultTpl, data)
+	}
+	return msg
+}
+
+func renderTeamHorseMsg(clientCfg conf.ClientUserConf, data horseMsgData) string {
+	if !data.HasHistory {
+		data.Horse = noGameHistory
+	}
+	return renderMsgTplOrDefault(clientCfg.TeamHorseMsgTpl, conf.DefaultTeamHorseMsgTpl, data)
+}
+func renderMergedHorseMsg(clientCfg conf.ClientUserConf, list []horseMsgData) string {
+	markNoGameHistory(list)
+	return renderMsgTplOrDefault(clientCfg.MergedHorseMsgTpl, conf.DefaultMergedHorseMsgTpl,
+		horseMsgListData{Players: list, WebsiteTitle: global.Conf.AdaptChatWebsiteTitle})
+}
+func renderEnemyHorseMsg(clientCfg conf.ClientUserConf, list []horseMsgData) string {
+	for i := range list {
+		list[i].IsEnemy = true
+	}
+	return renderMsgTplOrDefault(clientCfg.EnemyHorseMsgTpl, conf.DefaultEnemyHorseMsgTpl,
+		horseMsgListData{Players: list, WebsiteTitle: global.Conf.AdaptChatWebsiteTitle})
+}
+
+func markNoGameHistory(list []horseMsgData) {
+	for i := range list {
+		if !list[i].HasHistory {
+			list[i].Horse = noGameHistory
+		}
+	}
+}
+
+// 预览模板使用的示例数据
+func sampleHorseMsgDataList(clientCfg conf.ClientUserConf) []horseMsgData {
+	scoreCfg := global.GetScoreConf()
+	samples := []*lcu.UserScore{
+		{SummonerID: 1, SummonerName: "Faker#KR1", Score: 185.6, CurrKDA: [][3]int{{12, 1, 8}, {7, 2, 11}, {9, 0, 6}}},
+		{SummonerID: 2, SummonerName: "队友#HN1", Score: 112.3, CurrKDA: [][3]int{{3, 5, 9}, {4, 4, 4}}},
+		{SummonerID: 3, SummonerName: "新号#HN1", Score: 100},
+	}
+	list := make([]horseMsgData, 0, len(samples))
+	for _, scoreInfo := range samples {
+		data := newHorseMsgData(scoreInfo, scoreCfg, clientCfg, 1)
+		data.ChampionID = 103
+		data.ChampionName = "九尾妖狐"
+		list = append(list, data)
+	}
+	return list
+}
+
+func previewMsgTpl(tplType, tpl string) (string, error) {
+	clientCfg := global.GetClientUserConf()
+	list := sampleHorseMsgDataList(clientCfg)
+	switch tplType {
+	case msgTplTypeTeam:
+		return renderMsgTpl(tpl, conf.DefaultTeamHorseMsgTpl, list[0])
+	case msgTplTypeMerged:
+		markNoGameHistory(list)
+		return renderMsgTpl(tpl, conf.DefaultMergedHorseMsgTpl,
+			horseMsgListData{Players: list, WebsiteTitle: global.Conf.AdaptChatWebsiteTitle})
+	case msgTplTypeEnemy:
+		for i := range list {
+			list[i].IsEnemy = true
+			list[i].IsSelf = false
+		}
+		return renderMsgTpl(tpl, conf.DefaultEnemyHorseMsgTpl,
+			horseMsgListData{Players: list, WebsiteTitle: global.Conf.AdaptChatWebsiteTitle})
+	default:
+		return "", errors.Errorf("未知的模板类型:%s", tplType)
+	}
+}
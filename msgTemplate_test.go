@@ -0,0 +1,16 @@
+package hh_lol_prophet
+
+import (
+	"testing"
+)
+
+// 模板中可以使用英雄名称
+func TestPreviewMsgTplChampionName(t *testing.T) {
+	msg, err := previewMsgTpl(msgTplTypeEnemy, `{{range .Players}}{{.ChampionName}}{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg == "" {
+		t.Fatal("示例数据缺少英雄名称")
+	}
+}
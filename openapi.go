@@ -0,0 +1,342 @@
+package hh_lol_prophet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	ginApp "github.com/real-web-world/bdk/gin"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/db/models"
+)
+
+const (
+	openApiVersion        = "3.0.3"
+	openApiMethodAny      = "ANY"
+	openApiSchemaRefPath  = "#/components/schemas/"
+	openApiSecurityHeader = "apiTokenHeader"
+	openApiSecurityQuery  = "apiTokenQuery"
+)
+
+type (
+	// 单个路由的文档 Path为gin格式
+	openApiRoute struct {
+		Method      string
+		Path        string
+		Summary     string
+		Req         any    // 请求体 为nil时无请求体
+		Resp        any    // 响应中data字段 为nil时无data
+		RespContent string // 非json响应时的content-type 响应不使用通用结构
+		NeedToken   bool   // 开启本地api token后需要携带token
+	}
+	openApiSchemaBuilder struct {
+		schemas map[string]any
+	}
+)
+
+var (
+	// initV1Module中的每个路由都要在此处有对应文档
+	openApiRoutes = []openApiRoute{
+		{Method: http.MethodPost, Path: "/v1/horse/queryBySummonerName", Summary: "查询用户马匹信息",
+			Req: summonerNameReq{}, Resp: queryHorseResp{}},
+		{Method: http.MethodPost, Path: "/v1/horse/batchQuery", Summary: "按riot id批量查询马匹信息",
+			Req: riotIDListReq{}, Resp: []batchQueryHorseItem{}},
+		{Method: http.MethodPost, Path: "/v1/config/getAll", Summary: "获取所有配置",
+			Resp: conf.ClientUserConf{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/config/schema", Summary: "配置项说明",
+			Resp: conf.ClientConfSchema{}},
+		{Method: http.MethodPost, Path: "/v1/config/update", Summary: "更新配置 校验失败时data为各字段的错误",
+			Req: conf.UpdateClientUserConfReq{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/config/previewMsgTpl", Summary: "预览消息模板",
+			Req: previewMsgTplReq{}, Resp: previewMsgTplResp{}},
+		{Method: http.MethodPost, Path: "/v1/config/export", Summary: "导出配置",
+			Resp: configDoc{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/config/import", Summary: "导入配置 兼容旧版本文档",
+			Req: configDoc{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/profile/list", Summary: "配置方案列表",
+			Resp: profileListResp{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/profile/save", Summary: "新建或覆盖配置方案 conf为空时使用当前配置",
+			Req: saveProfileReq{}, Resp: conf.ClientProfile{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/profile/switch", Summary: "切换配置方案",
+			Req: profileNameReq{}, Resp: conf.ClientUserConf{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/profile/delete", Summary: "删除配置方案",
+			Req: profileNameReq{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/lcu/getAuthInfo", Summary: "获取lcu认证信息",
+			Resp: lcuAuthInfoResp{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/app/getInfo", Summary: "获取app信息",
+			Resp: global.AppInfo{}},
+		{Method: http.MethodPost, Path: "/v1/horse/copyHorseMsgToClipBoard", Summary: "复制马匹信息到剪切板",
+			NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/rune/list", Summary: "英雄符文配置列表",
+			Resp: []models.ChampionRune{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/rune/save", Summary: "保存英雄符文配置",
+			Req: saveChampionRuneReq{}, Resp: models.ChampionRune{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/rune/delete", Summary: "删除英雄符文配置",
+			Req: idReq{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/autoAccept/getPause", Summary: "获取是否暂停自动接受对局",
+			Resp: autoAcceptPauseResp{}},
+		{Method: http.MethodPost, Path: "/v1/autoAccept/setPause", Summary: "暂停/恢复自动接受对局",
+			Req: setAutoAcceptPauseReq{}, Resp: autoAcceptPauseResp{}, NeedToken: true},
+		{Method: http.MethodPost, Path: "/v1/match/current", Summary: "当前对局双方得分",
+			Resp: CurrentMatch{}},
+		{Method: http.MethodPost, Path: "/v1/match/current/refresh", Summary: "重新计算当前对局双方得分",
+			Resp: CurrentMatch{}, NeedToken: true},
+		{Method: http.MethodGet, Path: "/v1/game/report/:gameId", Summary: "赛后战绩报告",
+			Resp: models.GameReportData{}},
+		{Method: http.MethodGet, Path: "/v1/events", Summary: "事件推送 sse",
+			Resp: Event{}, RespContent: "text/event-stream"},
+		{Method: http.MethodGet, Path: "/v1/events/ws", Summary: "事件推送 websocket 每条消息为一个事件",
+			Resp: Event{}, RespContent: "application/json"},
+		{Method: http.MethodGet, Path: "/v1/openapi.json", Summary: "openapi文档",
+			RespContent: "application/json"},
+		{Method: http.MethodGet, Path: "/v1/diagnostics/bundle", Summary: "下载诊断包 包含日志 配置及最近的lcu错误",
+			RespContent: "application/zip", NeedToken: true},
+		{Method: openApiMethodAny, Path: "/v1/lcu/proxy/*any", Summary: "lcu反向代理",
+			RespContent: "application/json", NeedToken: true},
+	}
+	openApiDocOnce = sync.OnceValues(func() ([]byte, error) {
+		return json.Marshal(genOpenApiDoc(openApiRoutes))
+	})
+	// ANY 路由在文档中展开的方法
+	openApiAnyMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete}
+)
+
+func (api Api) GetOpenApiDoc(c *gin.Context) {
+	bts, err := openApiDocOnce()
+	if err != nil {
+		ginApp.GetApp(c).CommonError(err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", bts)
+}
+
+func genOpenApiDoc(routes []openApiRoute) map[string]any {
+	builder := &openApiSchemaBuilder{schemas: make(map[string]any)}
+	paths := make(map[string]map[string]any)
+	for _, route := range routes {
+		path, params := convertGinPathToOpenApi(route.Path)
+		if _, ok := paths[path]; !ok {
+			paths[path] = make(map[string]any)
+		}
+		methods := []string{route.Method}
+		if route.Method == openApiMethodAny {
+			methods = openApiAnyMethods
+		}
+		for _, method := range methods {
+			paths[path][strings.ToLower(method)] = builder.genOperation(route, params)
+		}
+	}
+	return map[string]any{
+		"openapi": openApiVersion,
+		"info": map[string]any{
+			"title":   global.Conf.AppName,
+			"version": APPVersion,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": builder.schemas,
+			"securitySchemes": map[string]any{
+				openApiSecurityHeader: map[string]any{
+					"type": "apiKey",
+					"in":   "header",
+					"name": localApiTokenHeader,
+				},
+				openApiSecurityQuery: map[string]any{
+					"type": "apiKey",
+					"in":   "query",
+					"name": localApiTokenQuery,
+				},
+			},
+		},
+	}
+}
+func (b *openApiSchemaBuilder) genOperation(route openApiRoute, params []string) map[string]any {
+	op := map[string]any{
+		"summary": route.Summary,
+	}
+	if route.NeedToken {
+		op["security"] = []any{
+			map[string]any{openApiSecurityHeader: []string{}},
+			map[string]any{openApiSecurityQuery: []string{}},
+		}
+	}
+	if len(params) > 0 {
+		paramList := make([]any, 0, len(params))
+		for _, name := range params {
+			paramList = append(paramList, map[string]any{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+		op["parameters"] = paramList
+	}
+	if route.Req != nil {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{
+					"schema": b.schema(reflect.TypeOf(route.Req)),
+				},
+			},
+		}
+	}
+	var content map[string]any
+	if route.RespContent != "" {
+		media := map[string]any{}
+		if route.Resp != nil {
+			media["schema"] = b.schema(reflect.TypeOf(route.Resp))
+		}
+		content = map[string]any{route.RespContent: media}
+	} else {
+		// 通用响应结构 code为0表示成功
+		respProps := map[string]any{
+			"code": map[string]any{"type": "integer"},
+			"msg":  map[string]any{"type": "string"},
+		}
+		if route.Resp != nil {
+			respProps["data"] = b.schema(reflect.TypeOf(route.Resp))
+		}
+		content = map[string]any{
+			"application/json": map[string]any{
+				"schema": map[string]any{
+					"type":       "object",
+					"properties": respProps,
+					"required":   []string{"code"},
+				},
+			},
+		}
+	}
+	op["responses"] = map[string]any{
+		"200": map[string]any{
+			"description": "OK",
+			"content":     content,
+		},
+	}
+	return op
+}
+
+// 具名结构体放入components 其余类型内联
+func (b *openApiSchemaBuilder) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Uint, reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		s := map[string]any{"type": "array", "items": b.schema(t.Elem())}
+		if t.Kind() == reflect.Array {
+			s["minItems"] = t.Len()
+			s["maxItems"] = t.Len()
+		}
+		return s
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := strings.ReplaceAll(t.String(), "_", "")
+		if _, ok := b.schemas[name]; !ok {
+			// 先占位 防止递归类型死循环
+			b.schemas[name] = map[string]any{}
+			b.schemas[name] = b.structSchema(t)
+		}
+		return map[string]any{"$ref": openApiSchemaRefPath + name}
+	default:
+		return map[string]any{}
+	}
+}
+func (b *openApiSchemaBuilder) structSchema(t reflect.Type) map[string]any {
+	props := make(map[string]any, t.NumField())
+	b.collectFields(t, props)
+	return map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+}
+func (b *openApiSchemaBuilder) collectFields(t reflect.Type, props map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		// 匿名嵌入且无json名称的结构体字段会被展开
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			b.collectFields(fieldType, props)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		props[name] = b.schema(field.Type)
+	}
+}
+
+// 将 :param 及 *param 转为 {param}
+func convertGinPathToOpenApi(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	params := make([]string, 0, 1)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// 返回已注册但没有文档的v1路由
+func listOpenApiMissingRoutes(routes gin.RoutesInfo) []string {
+	documented := make(map[string]struct{}, len(openApiRoutes))
+	for _, route := range openApiRoutes {
+		documented[route.Method+" "+route.Path] = struct{}{}
+	}
+	missing := make([]string, 0)
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/v1/") {
+			continue
+		}
+		if _, ok := documented[route.Method+" "+route.Path]; ok {
+			continue
+		}
+		if _, ok := documented[openApiMethodAny+" "+route.Path]; ok {
+			continue
+		}
+		missing = append(missing, fmt.Sprintf("%s %s", route.Method, route.Path))
+	}
+	slices.Sort(missing)
+	return missing
+}
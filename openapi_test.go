@@ -0,0 +1,17 @@
+package hh_lol_prophet
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 新增路由必须补充openapi文档
+func TestOpenApiCoverRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	RegisterRoutes(engine, &Api{})
+	if missing := listOpenApiMissingRoutes(engine.Routes()); len(missing) > 0 {
+		t.Fatalf("以下路由缺少openapi文档: %v", missing)
+	}
+}
@@ -0,0 +1,123 @@
+package hh_lol_prophet
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+	"github.com/real-web-world/hh-lol-prophet/services/logger"
+)
+
+// 结算前 点赞配置的队友 否则按配置跳过点赞
+func (p *Prophet) onPreEndOfGame(ctx context.Context) {
+	clientCfg := global.GetClientUserConf()
+	if !clientCfg.AutoSkipHonor && len(clientCfg.AutoHonorRiotIDList) == 0 {
+		return
+	}
+	cli := p.getLcuClient()
+	ballot, err := cli.GetHonorBallot(ctx)
+	if err != nil {
+		logger.Debug("获取点赞投票失败", zap.Error(err))
+		return
+	}
+	for _, ally := range ballot.EligibleAllies {
+		riotID := fmt.Sprintf("%s#%s", ally.GameName, ally.TagLine)
+		if !slices.Contains(clientCfg.AutoHonorRiotIDList, riotID) &&
+			!slices.Contains(clientCfg.AutoHonorRiotIDList, ally.SummonerName) {
+			continue
+		}
+		err = cli.HonorPlayer(ctx, ballot.GameId, models.HonorCategoryHeart, ally.SummonerId, ally.Puuid)
+		if err != nil {
+			logger.Debug("自动点赞失败", zap.Error(err), zap.String("riotID", riotID))
+		} else {
+			p.emitAutomation(AutomationActionHonor, gin.H{"riotID": riotID})
+		}
+		return
+	}
+	if clientCfg.AutoSkipHonor {
+		if err = cli.HonorPlayer(ctx, ballot.GameId, models.HonorCategoryOptOut, 0, ""); err != nil {
+			logger.Debug("跳过点赞失败", zap.Error(err))
+		} else {
+			p.emitAutomation(AutomationActionSkipHonor, nil)
+		}
+	}
+}
+
+// 结算 记录胜负并生成赛后报告 按配置返回房间并重新匹配
+func (p *Prophet) onEndOfGame(ctx context.Context) {
+	clientCfg := global.GetClientUserConf()
+	cli := p.getLcuClient()
+	eog, err := cli.GetEogStatsBlock(ctx)
+	if err != nil {
+		logger.Debug("获取结算数据失败", zap.Error(err))
+	}
+	lossStreak, isNewGame := p.recordGameResult(eog)
+	if isNewGame && eog.GameId != 0 {
+		go p.onGameReport(ctx, eog.GameId)
+	}
+	if !isNewGame || !clientCfg.AutoPlayAgain {
+		return
+	}
+	if err := cli.DismissStats(ctx); err != nil {
+		logger.Debug("关闭结算界面失败", zap.Error(err))
+	}
+	if err := cli.PlayAgain(ctx); err != nil {
+		logger.Debug("返回房间失败", zap.Error(err))
+		return
+	}
+	p.emitAutomation(AutomationActionPlayAgain, nil)
+	if !clientCfg.AutoRequeue {
+		return
+	}
+	if clientCfg.AutoRequeueStopAfterLosses > 0 && lossStreak >= clientCfg.AutoRequeueStopAfterLosses {
+		logger.Info("已连败,停止自动匹配", zap.Int("lossStreak", lossStreak))
+		return
+	}
+	if !sleepWithCtx(ctx, time.Duration(clientCfg.AutoRequeueDelaySec)*time.Second) {
+		return
+	}
+	// 等待期间可能已手动开始匹配或退出房间
+	session, err := cli.QueryGameFlowSession(ctx)
+	if err != nil || session.Phase != models.GameFlowLobby {
+		return
+	}
+	if err = cli.StartMatchmaking(ctx); err != nil {
+		logger.Debug("自动开始匹配失败", zap.Error(err))
+		return
+	}
+	p.emitAutomation(AutomationActionRequeue, nil)
+}
+
+// 返回当前连败场数 同一局重复结算或结算数据缺失时isNewGame为false 不执行后续自动操作
+func (p *Prophet) recordGameResult(eog *models.EogStatsBlock) (lossStreak int, isNewGame bool) {
+	isWin, hasPlayerTeam := false, false
+	if eog != nil {
+		for _, team := range eog.Teams {
+			if team.IsPlayerTeam {
+				isWin, hasPlayerTeam = team.IsWinningTeam, true
+				break
+			}
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !hasPlayerTeam {
+		return p.lossStreak, false
+	}
+	if eog.GameId != 0 && eog.GameId == p.lastEndGameID {
+		return p.lossStreak, false
+	}
+	p.lastEndGameID = eog.GameId
+	if isWin {
+		p.lossStreak = 0
+	} else {
+		p.lossStreak++
+	}
+	return p.lossStreak, true
+}
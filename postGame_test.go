@@ -0,0 +1,60 @@
+package hh_lol_prophet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+)
+
+func newTestEog(isWin bool) *models.EogStatsBlock {
+	eog := &models.EogStatsBlock{}
+	eog.Teams = append(eog.Teams, struct {
+		IsPlayerTeam  bool `json:"isPlayerTeam"`
+		IsWinningTeam bool `json:"isWinningTeam"`
+	}{IsPlayerTeam: true, IsWinningTeam: isWin})
+	return eog
+}
+
+func TestOnEndOfGameRequeue(t *testing.T) {
+	p, fake := newTestProphet(t, func(cfg *conf.ClientUserConf) {
+		cfg.AutoPlayAgain = true
+		cfg.AutoRequeue = true
+		cfg.AutoRequeueDelaySec = 0
+		cfg.AutoRequeueStopAfterLosses = 2
+	})
+	fake.GameFlowSession = &models.GameFlowSession{Phase: models.GameFlowLobby}
+	ctx := context.Background()
+
+	fake.EogStatsBlock = newTestEog(false)
+	p.onEndOfGame(ctx)
+	if fake.Called("PlayAgain") != 1 || fake.Called("StartMatchmaking") != 1 {
+		t.Fatalf("第1场失败后应返回房间并匹配: %v", fake.Calls())
+	}
+	// 连败达到上限后只返回房间 不再匹配
+	fake.Reset()
+	fake.EogStatsBlock = newTestEog(false)
+	p.onEndOfGame(ctx)
+	if fake.Called("PlayAgain") != 1 || fake.Called("StartMatchmaking") != 0 {
+		t.Fatalf("连败2场后不应继续匹配: %v", fake.Calls())
+	}
+	// 获胜后连败清零
+	fake.Reset()
+	fake.EogStatsBlock = newTestEog(true)
+	p.onEndOfGame(ctx)
+	if fake.Called("StartMatchmaking") != 1 {
+		t.Fatalf("获胜后应继续匹配: %v", fake.Calls())
+	}
+}
+
+func TestOnEndOfGameWithoutEog(t *testing.T) {
+	p, fake := newTestProphet(t, func(cfg *conf.ClientUserConf) {
+		cfg.AutoPlayAgain = true
+		cfg.AutoRequeue = true
+	})
+	p.onEndOfGame(context.Background())
+	if fake.Called("GetEogStatsBlock") != 1 || fake.Called("PlayAgain") != 0 {
+		t.Fatalf("结算数据缺失时不应返回房间: %v", fake.Calls())
+	}
+}
@@ -0,0 +1,220 @@
+package hh_lol_prophet
+
+import (
+	"encoding/json"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/db/models"
+	"github.com/real-web-world/hh-lol-prophet/services/logger"
+)
+
+const (
+	profileNameMaxLen       = 16 // 方案名称最大字符数
+	confChangeSourceProfile = "profile"
+)
+
+var (
+	errProfileNotFound = errors.New("配置方案不存在")
+)
+
+func checkProfileName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > profileNameMaxLen {
+		return errors.Errorf("方案名称不能为空且不超过%d个字符", profileNameMaxLen)
+	}
+	return nil
+}
+func listClientProfiles() ([]conf.ClientProfile, error) {
+	items, err := models.Config{}.ListByKeyPrefix(models.ProfileKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]conf.ClientProfile, 0, len(items))
+	for _, item := range items {
+		profile := conf.ClientProfile{}
+		if err = json.Unmarshal([]byte(item.Val), &profile); err != nil {
+			logger.Warn("配置方案解析失败", zap.Error(err), zap.String("key", item.Key))
+			continue
+		}
+		list = append(list, profile)
+	}
+	return list, nil
+}
+
+// 不存在时返回errProfileNotFound
+func findClientProfile(name string) (*conf.ClientProfile, error) {
+	item, err := models.Config{}.Find(models.ProfileKeyPrefix + name)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, errProfileNotFound
+	}
+	profile := &conf.ClientProfile{}
+	if err = json.Unmarshal([]byte(item.Val), profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// 同一队列只能绑定一个方案
+func saveClientProfile(profile conf.ClientProfile) error {
+	if err := checkProfileName(profile.Name); err != nil {
+		return err
+	}
+	if errs := conf.CheckClientUserConf(&profile.Conf, nil); len(errs) > 0 {
+		return errs
+	}
+	list, err := listClientProfiles()
+	if err != nil {
+		return err
+	}
+	for _, item := range list {
+		if item.Name == profile.Name {
+			continue
+		}
+		for _, queueID := range profile.QueueIDList {
+			if slices.Contains(item.QueueIDList, queueID) {
+				return errors.Errorf("队列%d已绑定方案%s", queueID, item.Name)
+			}
+		}
+	}
+	return putClientProfile(models.Config{}, profile)
+}
+
+// 写入方案 不做校验 m可在事务中执行
+func putClientProfile(m models.Config, profile conf.ClientProfile) error {
+	bts, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	return m.Save(models.ProfileKeyPrefix+profile.Name, string(bts))
+}
+func deleteClientProfile(name string) error {
+	if _, err := findClientProfile(name); err != nil {
+		return err
+	}
+	if err := (models.Config{}).Delete(models.ProfileKeyPrefix + name); err != nil {
+		return err
+	}
+	if getActiveProfileName() == name {
+		return models.Config{}.Delete(models.ActiveProfileKey)
+	}
+	return nil
+}
+
+// 未使用方案时返回空字符串
+func getActiveProfileName() string {
+	item, err := models.Config{}.Find(models.ActiveProfileKey)
+	if err != nil || item == nil {
+		return ""
+	}
+	return item.Val
+}
+func findProfileByQueueID(queueID int) (*conf.ClientProfile, error) {
+	list, err := listClientProfiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range list {
+		if slices.Contains(item.QueueIDList, queueID) {
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
+
+// 切换方案 本地api token 跨域及离线模式属于应用级设置 不随方案切换
+func (p *Prophet) switchClientProfile(name string) (*conf.ClientUserConf, error) {
+	profile, err := findClientProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	oldCfg, cfg, err := global.SwapClientUserConf(func(cur conf.ClientUserConf) (conf.ClientUserConf, error) {
+		cfg := keepAppLevelClientConf(profile.Conf, cur)
+		bts, err := json.Marshal(cfg)
+		if err != nil {
+			return cfg, err
+		}
+		return cfg, global.SqliteDB.Transaction(func(tx *gorm.DB) error {
+			m := models.Config{Tx: tx}
+			if err := m.Update(models.LocalClientConfKey, string(bts)); err != nil {
+				return err
+			}
+			return m.Save(models.ActiveProfileKey, name)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("已切换配置方案", zap.String("name", name))
+	p.emitEvent(EventTypeConfigChanged, ConfigChangedEventData{
+		Source:  confChangeSourceProfile + ":" + name,
+		Changes: diffConfFields("clientConf", oldCfg, cfg),
+	})
+	return &cfg, nil
+}
+
+// 使用cur中的应用级设置覆盖cfg
+func keepAppLevelClientConf(cfg, cur conf.ClientUserConf) conf.ClientUserConf {
+	cfg.LocalApiTokenEnabled = cur.LocalApiTokenEnabled
+	cfg.AllowOriginList = cur.AllowOriginList
+	cfg.OfflineMode = cur.OfflineMode
+	return cfg
+}
+
+// 修改配置后同步到当前方案 cfg需已校验 m可在事务中执行
+func syncActiveClientProfile(m models.Config, cfg conf.ClientUserConf) error {
+	active, err := m.Find(models.ActiveProfileKey)
+	if err != nil || active == nil {
+		return err
+	}
+	item, err := m.Find(models.ProfileKeyPrefix + active.Val)
+	if err != nil || item == nil {
+		return err
+	}
+	profile := conf.ClientProfile{}
+	if err = json.Unmarshal([]byte(item.Val), &profile); err != nil {
+		return err
+	}
+	profile.Conf = cfg
+	bts, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	return m.Update(item.Key, string(bts))
+}
+
+// 大厅队列变化时切换到绑定该队列的方案 串行执行 队列已再次变化时跳过
+func (p *Prophet) onLobbyQueueChange(queueID int) {
+	p.profileSwitchMu.Lock()
+	defer p.profileSwitchMu.Unlock()
+	p.mu.Lock()
+	isCurrent := p.lobbyQueueID == queueID
+	p.mu.Unlock()
+	if !isCurrent {
+		return
+	}
+	profile, err := findProfileByQueueID(queueID)
+	if err != nil || profile == nil {
+		return
+	}
+	if getActiveProfileName() == profile.Name {
+		return
+	}
+	if _, err = p.switchClientProfile(profile.Name); err != nil {
+		logger.Warn("自动切换配置方案失败", zap.Error(err), zap.String("name", profile.Name))
+		return
+	}
+	p.emitAutomation(AutomationActionSwitchProfile, gin.H{
+		"name":    profile.Name,
+		"queueID": queueID,
+	})
+}
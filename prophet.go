@@ -13,7 +13,6 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
-	"regexp"
 	"slices"
 	"strings"
 	"sync"
@@ -26,16 +25,19 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 
 	bdkgin "github.com/real-web-world/bdk/gin"
 	bdkmid "github.com/real-web-world/bdk/gin/middleware"
 
+	"github.com/real-web-world/hh-lol-prophet/conf"
 	"github.com/real-web-world/hh-lol-prophet/global"
 	"github.com/real-web-world/hh-lol-prophet/services/lcu"
 	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
 	"github.com/real-web-world/hh-lol-prophet/services/logger"
+	"github.com/real-web-world/hh-lol-prophet/services/metrics"
 )
 
 type (
@@ -46,13 +48,41 @@ type (
 		httpSrv      *http.Server
 		lcuPort      int
 		lcuToken     string
-		lcuActive    bool
-		currSummoner *models.SummonerProfileData
+		lcuActive    bool                        // 使用isLcuActive获取
+		currSummoner *models.SummonerProfileData // 使用getCurrSummoner获取
 		cancel       func()
 		api          *Api
 		mu           *sync.Mutex
-		GameState    GameState
+		fsm          *gameStateMachine
 		lcuRP        *lcu.RP
+		lcuCli       lcu.Api // 重连时替换 使用getLcuClient获取
+		// 当前客户端连接的ctx 断开时取消 使用getLcuCtx获取
+		lcuCtx    context.Context
+		lcuCancel func()
+		// 本次选人阶段已设置符文的英雄
+		runeAppliedChampID int
+		// 大乱斗正在换英雄或重随
+		aramSwapping bool
+		// 暂停自动接受/拒绝对局
+		autoAcceptPaused bool
+		// 最近一局结算的对局id及当前连败场数
+		lastEndGameID int64
+		lossStreak    int
+		// 聊天组id -> 发送队列
+		chatQueues map[string]*chatQueue
+		// 推送给前端的事件
+		events *eventHub
+		// 当前对局双方得分
+		currMatch CurrentMatch
+		// 本局赛前计算的得分 summonerID -> score 及其对应的对局id
+		predictScores map[int64]float64
+		predictGameID int64
+		// 当前游戏阶段的trace span
+		flowSpan gameFlowSpan
+		// 当前大厅的队列id
+		lobbyQueueID int
+		// 串行执行按队列自动切换方案
+		profileSwitchMu sync.Mutex
 	}
 	options struct {
 		debug       bool
@@ -61,16 +91,6 @@ type (
 	}
 )
 
-// gameState
-const (
-	GameStateNone        GameState = "none"
-	GameStateChampSelect GameState = "champSelect"
-	GameStateReadyCheck  GameState = "ReadyCheck"
-	GameStateInGame      GameState = "inGame"
-	GameStateOther       GameState = "other"
-	GameStateMatchmaking GameState = "Matchmaking"
-)
-
 var (
 	defaultOpts = &options{
 		debug:       false,
@@ -78,18 +98,18 @@ var (
 		httpAddr:    "127.0.0.1:4396",
 	}
 )
-var (
-	allowOriginRegex = regexp.MustCompile(".+?\\.buffge\\.com(:\\d+)?$")
-)
 
 func NewProphet(opts ...ApplyOption) *Prophet {
 	ctx, cancel := context.WithCancel(context.Background())
 	p := &Prophet{
-		ctx:       ctx,
-		cancel:    cancel,
-		mu:        &sync.Mutex{},
-		opts:      defaultOpts,
-		GameState: GameStateNone,
+		ctx:           ctx,
+		cancel:        cancel,
+		mu:            &sync.Mutex{},
+		opts:          defaultOpts,
+		fsm:           newGameStateMachine(ctx),
+		chatQueues:    make(map[string]*chatQueue),
+		predictScores: make(map[int64]float64, 10),
+		events:        newEventHub(),
 	}
 	if global.IsDevMode() {
 		opts = append(opts, WithDebug())
@@ -97,6 +117,7 @@ func NewProphet(opts ...ApplyOption) *Prophet {
 		opts = append(opts, WithProd())
 	}
 	p.api = &Api{p: p}
+	p.registerGameStateHooks()
 	for _, fn := range opts {
 		fn(p.opts)
 	}
@@ -105,33 +126,59 @@ func NewProphet(opts ...ApplyOption) *Prophet {
 func (p *Prophet) Run() error {
 	go p.MonitorStart()
 	go p.captureStartMessage()
+	go p.watchConfFile()
 	p.initGin()
 	go p.initWebView()
 	log.Printf("%s已启动 v%s -- %s", global.Conf.AppName, APPVersion, global.Conf.WebsiteTitle)
 	return p.notifyQuit()
 }
 func (p *Prophet) isLcuActive() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	return p.lcuActive
 }
+func (p *Prophet) getCurrSummoner() *models.SummonerProfileData {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.currSummoner
+}
+func (p *Prophet) setLcuConnected(summoner *models.SummonerProfileData) {
+	p.mu.Lock()
+	p.lcuActive = true
+	p.currSummoner = summoner
+	p.mu.Unlock()
+}
+
+// 返回断开前是否已连接
+func (p *Prophet) setLcuDisconnected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	wasActive := p.lcuActive
+	p.lcuActive = false
+	p.currSummoner = nil
+	return wasActive
+}
 func (p *Prophet) Stop() error {
 	if p.cancel != nil {
 		p.cancel()
 	}
+	p.endGameFlowSpan()
 	// stop all task
 	return nil
 }
 func (p *Prophet) MonitorStart() {
-	for {
+	for p.ctx.Err() == nil {
 		if !p.isLcuActive() {
 			port, token, err := lcu.GetLolClientApiInfo()
 			if err != nil {
 				if !errors.Is(lcu.ErrLolProcessNotFound, err) {
 					logger.Warn("获取lcu info 失败", zap.Error(err))
 				}
-				time.Sleep(time.Second)
+				sleepWithCtx(p.ctx, time.Second)
 				continue
 			}
 			p.initLcuClient(port, token)
+			p.initLcuCtx()
 			err = p.initLcuRP(port, token)
 			if err != nil {
 				logger.Debug("初始化lcuRP失败", zap.Error(err))
@@ -141,10 +188,14 @@ func (p *Prophet) MonitorStart() {
 				logger.Debug("游戏流程监视器 err:", zap.Error(err))
 			}
 			global.SetCurrSummoner(nil)
-			p.lcuActive = false
-			p.currSummoner = nil
+			// 客户端断开后取消当前阶段及本次连接的任务
+			p.transitGameState(GameStateNone, "")
+			p.cancelLcuCtx()
+			if p.setLcuDisconnected() {
+				p.emitEvent(EventTypeLcuDisconnected, nil)
+			}
 		}
-		time.Sleep(time.Second)
+		sleepWithCtx(p.ctx, time.Second)
 	}
 }
 
@@ -186,7 +237,37 @@ func (p *Prophet) notifyQuit() error {
 	return nil
 }
 func (p *Prophet) initLcuClient(port int, token string) {
-	lcu.InitCli(port, token)
+	p.mu.Lock()
+	p.lcuCli = lcu.NewClient(port, token)
+	p.mu.Unlock()
+}
+func (p *Prophet) initLcuCtx() {
+	p.mu.Lock()
+	p.lcuCtx, p.lcuCancel = context.WithCancel(p.ctx)
+	p.mu.Unlock()
+}
+func (p *Prophet) cancelLcuCtx() {
+	p.mu.Lock()
+	cancel := p.lcuCancel
+	p.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// 未连接客户端时返回全局ctx
+func (p *Prophet) getLcuCtx() context.Context {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.lcuCtx == nil {
+		return p.ctx
+	}
+	return p.lcuCtx
+}
+func (p *Prophet) getLcuClient() lcu.Api {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lcuCli
 }
 func (p *Prophet) initLcuRP(port int, token string) error {
 	rp, err := lcu.NewRP(port, token)
@@ -205,26 +286,32 @@ func (p *Prophet) initGameFlowMonitor(port int, authPwd string) error {
 	authSecret := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", lcu.AuthUserName, authPwd)))
 	header.Set("Authorization", "Basic "+authSecret)
 	u, _ := url.Parse(rawUrl)
-	c, _, err := dialer.Dial(u.String(), header)
+	c, _, err := dialer.DialContext(p.ctx, u.String(), header)
 	if err != nil {
+		metrics.IncLcuWsConnect(metrics.ResultFail)
 		return err
 	}
+	metrics.IncLcuWsConnect(metrics.ResultSuccess)
 	logger.Debug(fmt.Sprintf("connect to lcu %s", u.String()))
+	// 退出时关闭连接 结束读取消息
+	stopClose := context.AfterFunc(p.ctx, func() {
+		_ = c.Close()
+	})
 	defer func() {
+		stopClose()
 		_ = c.Close()
 	}()
+	var currSummoner *models.SummonerProfileData
 	err = retry.Do(func() error {
-		currSummoner, err := lcu.GetSummonerProfile()
-		if err == nil {
-			p.currSummoner = currSummoner
-		}
+		currSummoner, err = p.getLcuClient().GetSummonerProfile(p.ctx)
 		return err
-	}, retry.Attempts(5), retry.Delay(time.Second))
+	}, retry.Attempts(5), retry.Delay(time.Second), retry.Context(p.ctx))
 	if err != nil {
 		return errors.New("获取当前召唤师信息失败:" + err.Error())
 	}
-	global.SetCurrSummoner(p.currSummoner)
-	p.lcuActive = true
+	global.SetCurrSummoner(currSummoner)
+	p.setLcuConnected(currSummoner)
+	p.emitEvent(EventTypeLcuConnected, currSummoner)
 	err = c.WriteMessage(websocket.TextMessage, lcu.SubscribeAllEventMsg)
 	for {
 		msgType, message, err := c.ReadMessage()
@@ -248,48 +335,59 @@ func (p *Prophet) initGameFlowMonitor(port int, authPwd string) error {
 				logger.Debug("champSelectUpdateSessionEvt 解析结构体失败", zap.Error(err))
 				continue
 			}
+			// 离开选人阶段时取消 选人事件先于阶段切换到达时使用全局ctx
+			ctx := p.ctx
+			if state, stateCtx := p.fsm.currentCtx(); state == GameStateChampSelect {
+				ctx = stateCtx
+			}
 			go func() {
-				_ = p.onChampSelectSessionUpdate(sessionInfo)
+				_ = p.onChampSelectSessionUpdate(ctx, sessionInfo)
 			}()
+		case string(lcu.WsEvtLobbyUpdate):
+			// 离开大厅时队列id记为0 再次进入同一队列时会重新切换
+			lobby := &models.Lobby{}
+			if msg.EventType != lcu.WsEventTypeDelete && json.Unmarshal(msg.Data, lobby) != nil {
+				continue
+			}
+			queueID := int(lobby.GameConfig.QueueId)
+			p.mu.Lock()
+			changed := queueID != p.lobbyQueueID
+			p.lobbyQueueID = queueID
+			p.mu.Unlock()
+			if changed && queueID > 0 {
+				go p.onLobbyQueueChange(queueID)
+			}
 		default:
 
 		}
 	}
 }
-func (p *Prophet) onGameFlowUpdate(gameFlow models.GameFlow) {
-	logger.Debug("切换状态:" + string(gameFlow))
-	switch gameFlow {
-	case models.GameFlowChampionSelect:
-		logger.Info("进入英雄选择阶段,正在计算用户分数")
-		p.updateGameState(GameStateChampSelect)
-		go p.ChampionSelectStart()
-	case models.GameFlowNone:
-		p.updateGameState(GameStateNone)
-	case models.GameFlowMatchmaking:
-		p.updateGameState(GameStateMatchmaking)
-	case models.GameFlowInProgress:
-		p.updateGameState(GameStateInGame)
-		go p.CalcEnemyTeamScore()
-	case models.GameFlowReadyCheck:
-		p.updateGameState(GameStateReadyCheck)
-		clientCfg := global.GetClientUserConf()
-		if clientCfg.AutoAcceptGame {
-			go p.AcceptGame()
-		}
-	default:
-		p.updateGameState(GameStateOther)
-	}
-
-}
-func (p *Prophet) updateGameState(state GameState) {
+func (p *Prophet) resetRuneApplied() {
 	p.mu.Lock()
-	p.GameState = state
+	p.runeAppliedChampID = 0
 	p.mu.Unlock()
 }
-func (p *Prophet) getGameState() GameState {
+func (p *Prophet) applyChampionRuneOnce(ctx context.Context, championID int, position string) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-	return p.GameState
+	if p.runeAppliedChampID == championID {
+		p.mu.Unlock()
+		return
+	}
+	p.runeAppliedChampID = championID
+	p.mu.Unlock()
+	if err := applyChampionRune(ctx, p.getLcuClient(), championID, position); err != nil {
+		// 失败后允许下次选人事件重试
+		p.mu.Lock()
+		if p.runeAppliedChampID == championID {
+			p.runeAppliedChampID = 0
+		}
+		p.mu.Unlock()
+		logger.Warn("自动设置符文及召唤师技能失败", zap.Error(err), zap.Int("championID", championID),
+			zap.String("position", position))
+		p.emitError("自动设置符文及召唤师技能失败", err)
+		return
+	}
+	p.emitAutomation(AutomationActionSetRune, gin.H{"championID": championID, "position": position})
 }
 func (p *Prophet) captureStartMessage() {
 	logger.Info(global.Conf.AppName + "已启动")
@@ -308,12 +406,7 @@ func (p *Prophet) initGin() {
 		pprof.RouteRegister(engine.Group(""))
 	}
 	engine.Use(cors.New(cors.Config{
-		AllowOriginFunc: func(origin string) bool {
-			if global.IsDevMode() {
-				return true
-			}
-			return allowOriginRegex.MatchString(origin)
-		},
+		AllowOriginFunc:     isAllowOrigin,
 		AllowMethods:        []string{"*"},
 		AllowHeaders:        []string{"*"},
 		ExposeHeaders:       []string{"*"},
@@ -330,6 +423,43 @@ func (p *Prophet) initGin() {
 	}
 	p.httpSrv = srv
 }
+func isAllowOrigin(origin string) bool {
+	allowOriginList := global.GetClientUserConf().AllowOriginList
+	if len(allowOriginList) == 0 {
+		allowOriginList = conf.DefaultAllowOriginList
+	}
+	if global.IsDevMode() && matchOrigin(origin, conf.DevAllowOriginList) {
+		return true
+	}
+	return matchOrigin(origin, allowOriginList)
+}
+
+// 含://时需完整匹配origin 否则匹配域名 *.example.com 匹配所有子域名
+func matchOrigin(origin string, allowOriginList []string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := u.Hostname()
+	for _, allowOrigin := range allowOriginList {
+		switch {
+		case strings.Contains(allowOrigin, "://"):
+			if strings.EqualFold(strings.TrimSuffix(allowOrigin, "/"), origin) {
+				return true
+			}
+		case strings.HasPrefix(allowOrigin, "*."):
+			suffix := allowOrigin[1:]
+			if len(host) > len(suffix) && strings.HasSuffix(strings.ToLower(host), strings.ToLower(suffix)) {
+				return true
+			}
+		default:
+			if strings.EqualFold(allowOrigin, host) {
+				return true
+			}
+		}
+	}
+	return false
+}
 func (p *Prophet) initWebView() {
 	clientCfg := global.GetClientUserConf()
 	indexUrl := global.Conf.WebView.IndexUrl
@@ -339,22 +469,49 @@ func (p *Prophet) initWebView() {
 		log.Println("自动打开浏览器选项已关闭,手动打开请访问 " + websiteUrl)
 		return
 	}
+	if clientCfg.LocalApiTokenEnabled {
+		log.Println("已开启本地api token,请在界面中填写: " + global.GetLocalApiToken())
+	}
 	cmd := exec.Command("cmd", "/c", "start", websiteUrl)
 	_ = cmd.Run()
 	log.Println("界面已在浏览器中打开,若未打开请手动访问 " + websiteUrl)
 	return
 }
-func (p *Prophet) ChampionSelectStart() {
+
+// 获取当前对局id 失败时返回0
+func queryCurrGameID(ctx context.Context, cli lcu.Api) int64 {
+	var gameID int64
+	err := retry.Do(func() error {
+		session, err := cli.QueryGameFlowSession(ctx)
+		if err != nil {
+			return err
+		}
+		gameID = session.GameData.GameId
+		return nil
+	}, retry.Attempts(3), retry.Delay(time.Millisecond*500), retry.LastErrorOnly(true), retry.Context(ctx))
+	if err != nil && ctx.Err() == nil {
+		logger.Warn("获取当前对局id失败", zap.Error(err))
+	}
+	return gameID
+}
+func (p *Prophet) ChampionSelectStart(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "ChampionSelectStart")
+	defer span.End()
+	cli := p.getLcuClient()
 	clientCfg := global.GetClientUserConf()
-	sendConversationMsgDelayCtx, cancel := context.WithTimeout(context.Background(),
+	sendConversationMsgDelayCtx, cancel := context.WithTimeout(ctx,
 		time.Second*time.Duration(clientCfg.ChooseChampSendMsgDelaySec))
 	defer cancel()
 	var conversationID string
 	var summonerIDList []int64
 	for i := 0; i < 3; i++ {
-		time.Sleep(time.Second)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
 		// 获取队伍所有用户信息
-		conversationID, summonerIDList, _ = getTeamUsers()
+		conversationID, summonerIDList, _ = getTeamUsers(ctx, cli)
 		if len(summonerIDList) != 5 {
 			continue
 		}
@@ -367,91 +524,51 @@ func (p *Prophet) ChampionSelectStart() {
 		return
 	}
 	logger.Debug("队伍人员列表:", zap.Any("summonerIDList", summonerIDList))
-	// 查询所有用户的信息并计算得分
-	g := errgroup.Group{}
-	summonerScores := make([]*lcu.UserScore, 0, 5)
-	mu := sync.Mutex{}
-	summonerIDMapInfo, err := listSummoner(summonerIDList)
-	if err != nil {
-		logger.Error("查询召唤师信息失败", zap.Error(err), zap.Any("summonerIDList", summonerIDList))
+	gameID := queryCurrGameID(ctx, cli)
+	summonerScores, err := p.calcSummonerScores(ctx, gameID, summonerIDList, false)
+	// 计算期间已离开选人阶段
+	if err != nil || ctx.Err() != nil {
 		return
 	}
-	for _, summoner := range summonerIDMapInfo {
-		summoner := summoner
-		summonerID := summoner.SummonerId
-		g.Go(func() error {
-			actScore, err := GetUserScore(summoner)
-			if err != nil {
-				logger.Error("计算用户得分失败", zap.Error(err), zap.Int64("summonerID", summonerID))
-				return nil
-			}
-			mu.Lock()
-			summonerScores = append(summonerScores, actScore)
-			mu.Unlock()
-			return nil
-		})
-	}
-	_ = g.Wait()
-	slices.SortFunc(summonerScores, func(a, b *lcu.UserScore) int {
-		return cmp.Compare(b.Score, a.Score)
-	})
 	// 根据所有用户的分数判断小代上等马中等马下等马
 	//for _, score := range summonerIDMapScore {
 	//	fmt.Printf("用户:%s,得分:%.2f\n", score.SummonerName, score.Score)
 	//}
 	scoreCfg := global.GetScoreConf()
+	var selfID int64
+	if currSummoner := p.getCurrSummoner(); currSummoner != nil {
+		selfID = currSummoner.SummonerId
+	}
+	msgDataList := make([]horseMsgData, 0, len(summonerScores))
+	for _, scoreInfo := range summonerScores {
+		msgDataList = append(msgDataList, newHorseMsgData(scoreInfo, scoreCfg, clientCfg, selfID))
+	}
+	p.setCurrentMatchTeam(msgDataList)
 	allMsg := ""
-	mergedMsg := ""
 	// 发送到选人界面
-	for _, scoreInfo := range summonerScores {
-		var horse string
-		horseIdx := 0
-		for i, v := range scoreCfg.Horse {
-			if scoreInfo.Score >= v.Score {
-				horse = clientCfg.HorseNameConf[i]
-				horseIdx = i
-				break
-			}
-		}
-		if len(scoreInfo.CurrKDA) == 0 {
-			horse = "未查询到战绩"
-		}
-		currKDASb := strings.Builder{}
-		for i := 0; i < 5 && i < len(scoreInfo.CurrKDA); i++ {
-			currKDASb.WriteString(fmt.Sprintf("%d-%d-%d  ", scoreInfo.CurrKDA[i][0], scoreInfo.CurrKDA[i][1],
-				scoreInfo.CurrKDA[i][2]))
-		}
-		currKDAMsg := currKDASb.String()
-		if len(currKDAMsg) > 0 {
-			currKDAMsg = currKDAMsg[:len(currKDAMsg)-1]
-		}
-		msg := fmt.Sprintf("%s(%d): %s %s", horse, int(scoreInfo.Score), scoreInfo.SummonerName,
-			currKDAMsg)
+	for _, msgData := range msgDataList {
+		msg := renderTeamHorseMsg(clientCfg, msgData)
 		<-sendConversationMsgDelayCtx.Done()
-		if clientCfg.AutoSendTeamHorse {
-			mergedMsg += msg + "\n"
+		if ctx.Err() != nil {
+			return
 		}
 		if !clientCfg.AutoSendTeamHorse {
-			if !scoreCfg.MergeMsg && !clientCfg.ShouldSendSelfHorse && p.currSummoner != nil &&
-				scoreInfo.SummonerID == p.currSummoner.SummonerId {
+			if !scoreCfg.MergeMsg && !clientCfg.ShouldSendSelfHorse && msgData.IsSelf {
 				continue
 			}
 			allMsg += msg + "\n"
-			mergedMsg += msg + "\n"
 			continue
 		}
-		if !clientCfg.ShouldSendSelfHorse && p.currSummoner != nil &&
-			scoreInfo.SummonerID == p.currSummoner.SummonerId {
+		if !clientCfg.ShouldSendSelfHorse && msgData.IsSelf {
 			continue
 		}
-		if !clientCfg.ChooseSendHorseMsg[horseIdx] {
+		if !clientCfg.ChooseSendHorseMsg[msgData.HorseIdx] {
 			continue
 		}
 		if scoreCfg.MergeMsg {
 			continue
 		}
-		_ = SendConversationMsg(msg, conversationID)
-		time.Sleep(time.Millisecond * 2100)
+		p.sendChatMsg(ctx, conversationID, msg)
 	}
 	if !clientCfg.AutoSendTeamHorse {
 		_ = clipboard.WriteAll(allMsg)
@@ -461,27 +578,34 @@ func (p *Prophet) ChampionSelectStart() {
 		return
 	}
 	if scoreCfg.MergeMsg {
-		_ = SendConversationMsg(mergedMsg, conversationID)
+		p.sendChatMsg(ctx, conversationID, renderMergedHorseMsg(clientCfg, msgDataList))
 	}
 }
-func (p *Prophet) AcceptGame() {
-	_ = lcu.AcceptGame()
+func (p *Prophet) AcceptGame(ctx context.Context) {
+	if err := p.getLcuClient().AcceptGame(ctx); err == nil {
+		p.emitAutomation(AutomationActionAcceptGame, nil)
+	}
 }
-func (p *Prophet) CalcEnemyTeamScore() {
+func (p *Prophet) CalcEnemyTeamScore(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "CalcEnemyTeamScore")
+	defer span.End()
 	// 获取当前游戏进程
-	session, err := lcu.QueryGameFlowSession()
+	session, err := p.getLcuClient().QueryGameFlowSession(ctx)
 	if err != nil {
 		return
 	}
 	if session.Phase != models.GameFlowInProgress {
 		return
 	}
-	if p.currSummoner == nil {
+	currSummoner := p.getCurrSummoner()
+	if currSummoner == nil {
 		return
 	}
-	selfID := p.currSummoner.SummonerId
+	selfID := currSummoner.SummonerId
 	selfTeamUsers, enemyTeamUsers := getAllUsersFromSession(selfID, session)
 	_ = selfTeamUsers
+	championIDMap := getChampionIDMapFromSession(session)
+	p.updateCurrentMatchChampions(championIDMap)
 	summonerIDList := enemyTeamUsers
 	// if !false && global.IsDevMode() {
 	// 	summonerIDList = []int64{2964390005, 4103784618, 4132401993, 4118593599, 4019221688}
@@ -491,92 +615,95 @@ func (p *Prophet) CalcEnemyTeamScore() {
 	if len(summonerIDList) == 0 {
 		return
 	}
-	// 查询所有用户的信息并计算得分
+	summonerScores, err := p.calcSummonerScores(ctx, session.GameData.GameId, summonerIDList, true)
+	if err != nil || ctx.Err() != nil {
+		return
+	}
+	scoreCfg := global.GetScoreConf()
+	clientCfg := global.GetClientUserConf()
+	championNames, err := listChampionNames(ctx, p.getLcuClient())
+	if err != nil {
+		logger.Debug("获取英雄列表失败,模板中英雄名称为空", zap.Error(err))
+	}
+	// 根据所有用户的分数判断小代上等马中等马下等马
+	msgDataList := make([]horseMsgData, 0, len(summonerScores))
+	for _, scoreInfo := range summonerScores {
+		msgData := newHorseMsgData(scoreInfo, scoreCfg, clientCfg, selfID)
+		msgData.ChampionID = championIDMap[scoreInfo.SummonerID]
+		msgData.ChampionName = championNames[msgData.ChampionID]
+		msgData.IsEnemy = true
+		msgDataList = append(msgDataList, msgData)
+	}
+	p.setCurrentMatchEnemy(msgDataList)
+	// 控制台与剪切板使用同一个敌方模板
+	enemyMsg := renderEnemyHorseMsg(clientCfg, slices.Clone(msgDataList))
+	if len(summonerScores) > 0 {
+		fmt.Println("敌方用户详情:")
+		fmt.Println(enemyMsg)
+	}
+	_ = clipboard.WriteAll(enemyMsg)
+}
+
+// 查询所有用户的信息并计算得分 按得分从高到低排序 得分记为gameID的赛前得分
+func (p *Prophet) calcSummonerScores(ctx context.Context, gameID int64, summonerIDList []int64,
+	isEnemy bool) (summonerScores []*lcu.UserScore, err error) {
+	ctx, span := tracer.Start(ctx, "calcSummonerScores", trace.WithAttributes(
+		traceAttrSummonerCount.Int(len(summonerIDList)), traceAttrIsEnemy.Bool(isEnemy)))
+	defer func() {
+		endSpan(span, err)
+	}()
 	g := errgroup.Group{}
-	summonerScores := make([]*lcu.UserScore, 0, 5)
+	summonerScores = make([]*lcu.UserScore, 0, 5)
 	mu := sync.Mutex{}
-	summonerIDMapInfo, err := listSummoner(summonerIDList)
+	cli := p.getLcuClient()
+	summonerIDMapInfo, err := listSummoner(ctx, cli, summonerIDList)
 	if err != nil {
 		logger.Error("查询召唤师信息失败", zap.Error(err), zap.Any("summonerIDList", summonerIDList))
-		return
+		p.emitError("查询召唤师信息失败", err)
+		return nil, err
 	}
 	for _, summoner := range summonerIDMapInfo {
 		summoner := summoner
 		summonerID := summoner.SummonerId
 		g.Go(func() error {
-			actScore, err := GetUserScore(summoner)
+			if ctx.Err() != nil {
+				return nil
+			}
+			actScore, err := GetUserScore(ctx, cli, summoner)
 			if err != nil {
 				logger.Error("计算用户得分失败", zap.Error(err), zap.Int64("summonerID", summonerID))
+				p.emitError("计算用户得分失败", err)
 				return nil
 			}
+			p.emitPlayerScore(actScore, isEnemy)
 			mu.Lock()
 			summonerScores = append(summonerScores, actScore)
-			//summonerIDMapScore[summonerID] = *actScore
 			mu.Unlock()
 			return nil
 		})
 	}
-	scoreCfg := global.GetScoreConf()
-	clientCfg := global.GetClientUserConf()
 	_ = g.Wait()
-	if len(summonerScores) > 0 {
-		fmt.Println("敌方用户详情:")
-	}
+	p.savePredictScores(gameID, summonerScores)
 	slices.SortFunc(summonerScores, func(a, b *lcu.UserScore) int {
 		return cmp.Compare(b.Score, a.Score)
 	})
-	// 根据所有用户的分数判断小代上等马中等马下等马
-	for _, score := range summonerScores {
-		var horse string
-		for i, v := range scoreCfg.Horse {
-			if score.Score >= v.Score {
-				horse = clientCfg.HorseNameConf[i]
-				break
-			}
-		}
-		currKDASb := strings.Builder{}
-		for i := 0; i < 5 && i < len(score.CurrKDA); i++ {
-			currKDASb.WriteString(fmt.Sprintf("%d-%d-%d  ", score.CurrKDA[i][0], score.CurrKDA[i][1],
-				score.CurrKDA[i][2]))
-		}
-		currKDAMsg := currKDASb.String()
-		//log.Printf("敌方用户:%s (%s) 得分:%.2f,kda:%s\n", score.SummonerName, horse, score.Score, currKDAMsg)
-		fmt.Printf("%s(%d): %s %s\n", horse, int(score.Score), score.SummonerName,
-			currKDAMsg)
-	}
-	allMsg := ""
-	// 发送到选人界面
-	for _, scoreInfo := range summonerScores {
-		time.Sleep(time.Second / 2)
-		var horse string
-		// horseIdx := 0
-		for i, v := range scoreCfg.Horse {
-			if scoreInfo.Score >= v.Score {
-				horse = clientCfg.HorseNameConf[i]
-				// horseIdx = i
-				break
-			}
-		}
-		currKDASb := strings.Builder{}
-		for i := 0; i < 5 && i < len(scoreInfo.CurrKDA); i++ {
-			currKDASb.WriteString(fmt.Sprintf("%d-%d-%d  ", scoreInfo.CurrKDA[i][0], scoreInfo.CurrKDA[i][1],
-				scoreInfo.CurrKDA[i][2]))
-		}
-		currKDAMsg := currKDASb.String()
-		if len(currKDAMsg) > 0 {
-			currKDAMsg = currKDAMsg[:len(currKDAMsg)-1]
-		}
-		msg := fmt.Sprintf("%s(%d): %s %s  -- %s", horse, int(scoreInfo.Score), scoreInfo.SummonerName,
-			currKDAMsg, global.Conf.AdaptChatWebsiteTitle)
-		allMsg += msg + "\n"
-	}
-	_ = clipboard.WriteAll(allMsg)
+	return summonerScores, nil
 }
-func (p *Prophet) onChampSelectSessionUpdate(sessionInfo *models.ChampSelectSessionInfo) error {
+func (p *Prophet) onChampSelectSessionUpdate(ctx context.Context, sessionInfo *models.ChampSelectSessionInfo) error {
 	var userPickActionID, userBanActionID, pickChampionID int
-	var isSelfPick, isSelfBan, pickIsInProgress, banIsInProgress bool
+	var isSelfPick, isSelfBan, pickIsInProgress, banIsInProgress, pickIsCompleted bool
 	alloyPrePickChampionIDSet := make(map[int]struct{}, 5)
+	cli := p.getLcuClient()
+	clientCfg := global.GetClientUserConf()
+	p.updateCurrentMatchChampions(getChampionIDMapFromChampSelect(sessionInfo))
+	if clientCfg.AramAutoSwap {
+		p.onAramBenchUpdate(ctx, sessionInfo, clientCfg)
+	}
+	// 大乱斗等没有选人操作的模式 英雄取自己在队伍中的英雄
 	if len(sessionInfo.Actions) == 0 {
+		if championID := getLocalPlayerChampionID(sessionInfo); clientCfg.AutoSetRuneAndSpell && championID > 0 {
+			p.applyChampionRuneOnce(ctx, championID, getLocalPlayerPosition(sessionInfo))
+		}
 		return nil
 	}
 	for _, actions := range sessionInfo.Actions {
@@ -592,6 +719,7 @@ func (p *Prophet) onChampSelectSessionUpdate(sessionInfo *models.ChampSelectSess
 				userPickActionID = action.Id
 				pickChampionID = action.ChampionId
 				pickIsInProgress = action.IsInProgress
+				pickIsCompleted = action.Completed
 			} else if action.Type == lcu.ChampSelectPatchTypeBan {
 				isSelfBan = true
 				userBanActionID = action.Id
@@ -600,18 +728,24 @@ func (p *Prophet) onChampSelectSessionUpdate(sessionInfo *models.ChampSelectSess
 			break
 		}
 	}
-	clientCfg := global.GetClientUserConf()
 	if clientCfg.AutoPickChampID != 0 && isSelfPick {
 		if pickIsInProgress {
-			_ = lcu.PickChampion(clientCfg.AutoPickChampID, userPickActionID)
+			if err := cli.PickChampion(ctx, clientCfg.AutoPickChampID, userPickActionID); err == nil {
+				p.emitAutomation(AutomationActionPickChampion, gin.H{"championID": clientCfg.AutoPickChampID})
+			}
 		} else if pickChampionID == 0 {
-			_ = lcu.PrePickChampion(clientCfg.AutoPickChampID, userPickActionID)
+			_ = cli.PrePickChampion(ctx, clientCfg.AutoPickChampID, userPickActionID)
 		}
 	}
 	if clientCfg.AutoBanChampID != 0 && isSelfBan && banIsInProgress {
 		if _, exist := alloyPrePickChampionIDSet[clientCfg.AutoBanChampID]; !exist {
-			_ = lcu.BanChampion(clientCfg.AutoBanChampID, userBanActionID)
+			if err := cli.BanChampion(ctx, clientCfg.AutoBanChampID, userBanActionID); err == nil {
+				p.emitAutomation(AutomationActionBanChampion, gin.H{"championID": clientCfg.AutoBanChampID})
+			}
 		}
 	}
+	if clientCfg.AutoSetRuneAndSpell && isSelfPick && pickIsCompleted && pickChampionID > 0 {
+		p.applyChampionRuneOnce(ctx, pickChampionID, getLocalPlayerPosition(sessionInfo))
+	}
 	return nil
 }
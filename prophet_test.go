@@ -0,0 +1,36 @@
+package hh_lol_prophet
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu/lcutest"
+)
+
+// 使用假lcu客户端创建Prophet 测试结束后还原客户端配置
+func newTestProphet(t *testing.T, setConf func(cfg *conf.ClientUserConf)) (*Prophet, *lcutest.Fake) {
+	t.Helper()
+	if global.Logger == nil {
+		global.Logger = zap.NewNop().Sugar()
+	}
+	old, _, _ := global.SwapClientUserConf(func(cur conf.ClientUserConf) (conf.ClientUserConf, error) {
+		cfg := cur
+		setConf(&cfg)
+		return cfg, nil
+	})
+	t.Cleanup(func() {
+		_, _, _ = global.SwapClientUserConf(func(conf.ClientUserConf) (conf.ClientUserConf, error) {
+			return old, nil
+		})
+	})
+	fake := lcutest.NewFake()
+	p := NewProphet()
+	p.lcuCli = fake
+	t.Cleanup(func() {
+		_ = p.Stop()
+	})
+	return p, fake
+}
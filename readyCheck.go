@@ -0,0 +1,109 @@
+package hh_lol_prophet
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/global"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+	"github.com/real-web-world/hh-lol-prophet/services/logger"
+)
+
+type readyCheckAction int
+
+const (
+	readyCheckActionNone readyCheckAction = iota
+	readyCheckActionAccept
+	readyCheckActionDecline
+)
+
+// 根据配置决定如何处理对局准备确认 自动拒绝优先于自动接受
+func decideReadyCheckAction(clientCfg conf.ClientUserConf, queueID int, isLobbyFull, isPaused bool) readyCheckAction {
+	if isPaused {
+		return readyCheckActionNone
+	}
+	if slices.Contains(clientCfg.AutoDeclineQueueIDList, queueID) {
+		return readyCheckActionDecline
+	}
+	if !clientCfg.AutoAcceptGame {
+		return readyCheckActionNone
+	}
+	if len(clientCfg.AutoAcceptQueueIDList) > 0 && !slices.Contains(clientCfg.AutoAcceptQueueIDList, queueID) {
+		return readyCheckActionNone
+	}
+	if clientCfg.AutoAcceptOnlyFullLobby && !isLobbyFull {
+		return readyCheckActionNone
+	}
+	return readyCheckActionAccept
+}
+
+func readyCheckDelay(delaySec [2]int) time.Duration {
+	minSec, maxSec := delaySec[0], delaySec[1]
+	if minSec < 0 {
+		minSec = 0
+	}
+	if maxSec <= minSec {
+		return time.Duration(minSec) * time.Second
+	}
+	delayMs := minSec*1000 + rand.IntN((maxSec-minSec)*1000+1)
+	return time.Duration(delayMs) * time.Millisecond
+}
+
+// ctx在离开接受对局阶段时取消
+func (p *Prophet) onReadyCheck(ctx context.Context) {
+	clientCfg := global.GetClientUserConf()
+	if !clientCfg.AutoAcceptGame && len(clientCfg.AutoDeclineQueueIDList) == 0 {
+		return
+	}
+	queueID := 0
+	isLobbyFull := false
+	cli := p.getLcuClient()
+	lobby, err := cli.GetLobby(ctx)
+	if err != nil {
+		logger.Debug("获取当前房间失败", zap.Error(err))
+	} else {
+		queueID = int(lobby.GameConfig.QueueId)
+		isLobbyFull = lobby.GameConfig.MaxLobbySize > 0 && len(lobby.Members) >= lobby.GameConfig.MaxLobbySize
+	}
+	action := decideReadyCheckAction(clientCfg, queueID, isLobbyFull, p.isAutoAcceptPaused())
+	if action == readyCheckActionNone {
+		return
+	}
+	if !sleepWithCtx(ctx, readyCheckDelay(clientCfg.AutoAcceptDelaySec)) {
+		return
+	}
+	// 等待期间可能已手动处理或暂停
+	if p.isAutoAcceptPaused() {
+		return
+	}
+	readyCheck, err := cli.GetReadyCheck(ctx)
+	if err != nil || readyCheck.State != models.ReadyCheckStateInProgress ||
+		readyCheck.PlayerResponse != models.ReadyCheckResponseNone {
+		return
+	}
+	switch action {
+	case readyCheckActionAccept:
+		p.AcceptGame(ctx)
+	case readyCheckActionDecline:
+		logger.Info("自动拒绝对局", zap.Int("queueID", queueID))
+		if err = cli.DeclineGame(ctx); err == nil {
+			p.emitAutomation(AutomationActionDeclineGame, gin.H{"queueID": queueID})
+		}
+	}
+}
+func (p *Prophet) isAutoAcceptPaused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.autoAcceptPaused
+}
+func (p *Prophet) setAutoAcceptPaused(paused bool) {
+	p.mu.Lock()
+	p.autoAcceptPaused = paused
+	p.mu.Unlock()
+}
@@ -0,0 +1,43 @@
+package hh_lol_prophet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/real-web-world/hh-lol-prophet/conf"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+)
+
+func TestOnReadyCheck(t *testing.T) {
+	p, fake := newTestProphet(t, func(cfg *conf.ClientUserConf) {
+		cfg.AutoAcceptGame = true
+		cfg.AutoAcceptDelaySec = [2]int{0, 0}
+		cfg.AutoDeclineQueueIDList = []int{int(models.NormalQueueID)}
+	})
+	fake.ReadyCheck = &models.ReadyCheck{
+		State:          models.ReadyCheckStateInProgress,
+		PlayerResponse: models.ReadyCheckResponseNone,
+	}
+	ctx := context.Background()
+
+	fake.Lobby = &models.Lobby{}
+	fake.Lobby.GameConfig.QueueId = models.RankSoleQueueID
+	p.onReadyCheck(ctx)
+	if fake.Called("AcceptGame") != 1 || fake.Called("DeclineGame") != 0 {
+		t.Fatalf("应自动接受对局: %v", fake.Calls())
+	}
+	fake.Reset()
+	fake.Lobby.GameConfig.QueueId = models.NormalQueueID
+	p.onReadyCheck(ctx)
+	if fake.Called("AcceptGame") != 0 || fake.Called("DeclineGame") != 1 {
+		t.Fatalf("应自动拒绝配置的队列: %v", fake.Calls())
+	}
+	// 暂停后不处理
+	fake.Reset()
+	p.setAutoAcceptPaused(true)
+	fake.Lobby.GameConfig.QueueId = models.RankSoleQueueID
+	p.onReadyCheck(ctx)
+	if fake.Called("AcceptGame") != 0 {
+		t.Fatalf("暂停后不应自动接受对局: %v", fake.Calls())
+	}
+}
@@ -2,10 +2,14 @@ package hh_lol_prophet
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/hh-lol-prophet/services/metrics"
 )
 
 func RegisterRoutes(r *gin.Engine, api *Api) {
 	r.Any("test", api.DevHand)
+	// prometheus指标
+	r.GET("metrics", gin.WrapH(metrics.Handler()))
 	initV1Module(r, api)
 }
 
@@ -13,16 +17,58 @@ func initV1Module(r *gin.Engine, api *Api) {
 	v1 := r.Group("v1")
 	// 查询用户马匹信息
 	v1.POST("horse/queryBySummonerName", api.ProphetActiveMid, api.QueryHorseBySummonerName)
+	// 按riot id批量查询马匹信息
+	v1.POST("horse/batchQuery", api.ProphetActiveMid, api.BatchQueryHorse)
 	// 获取所有配置
-	v1.POST("config/getAll", api.GetAllConf)
+	v1.POST("config/getAll", api.LocalApiTokenMid, api.GetAllConf)
+	// 配置项说明
+	v1.POST("config/schema", api.GetConfSchema)
 	// 更新配置
-	v1.POST("config/update", api.UpdateClientConf)
+	v1.POST("config/update", api.LocalApiTokenMid, api.UpdateClientConf)
+	// 预览消息模板
+	v1.POST("config/previewMsgTpl", api.PreviewMsgTpl)
+	// 导出配置
+	v1.POST("config/export", api.LocalApiTokenMid, api.ExportConf)
+	// 导入配置
+	v1.POST("config/import", api.LocalApiTokenMid, api.ImportConf)
+	// 配置方案列表
+	v1.POST("profile/list", api.LocalApiTokenMid, api.ListProfile)
+	// 保存配置方案
+	v1.POST("profile/save", api.LocalApiTokenMid, api.SaveProfile)
+	// 切换配置方案
+	v1.POST("profile/switch", api.LocalApiTokenMid, api.SwitchProfile)
+	// 删除配置方案
+	v1.POST("profile/delete", api.LocalApiTokenMid, api.DelProfile)
 	// 获取lcu认证信息
-	v1.POST("lcu/getAuthInfo", api.GetLcuAuthInfo)
+	v1.POST("lcu/getAuthInfo", api.LocalApiTokenMid, api.GetLcuAuthInfo)
 	// 获取app信息
 	v1.POST("app/getInfo", api.GetAppInfo)
 	// 复制马匹信息到剪切板
-	v1.POST("horse/copyHorseMsgToClipBoard", api.CopyHorseMsgToClipBoard)
+	v1.POST("horse/copyHorseMsgToClipBoard", api.LocalApiTokenMid, api.CopyHorseMsgToClipBoard)
+	// 英雄符文配置列表
+	v1.POST("rune/list", api.LocalApiTokenMid, api.ListChampionRune)
+	// 保存英雄符文配置
+	v1.POST("rune/save", api.LocalApiTokenMid, api.SaveChampionRune)
+	// 删除英雄符文配置
+	v1.POST("rune/delete", api.LocalApiTokenMid, api.DelChampionRune)
+	// 获取是否暂停自动接受对局
+	v1.POST("autoAccept/getPause", api.GetAutoAcceptPause)
+	// 暂停/恢复自动接受对局
+	v1.POST("autoAccept/setPause", api.LocalApiTokenMid, api.SetAutoAcceptPause)
+	// 当前对局双方得分
+	v1.POST("match/current", api.GetCurrentMatch)
+	// 重新计算当前对局双方得分
+	v1.POST("match/current/refresh", api.LocalApiTokenMid, api.ProphetActiveMid, api.RefreshCurrentMatch)
+	// 赛后战绩报告
+	v1.GET("game/report/:gameId", api.GetGameReport)
+	// 事件推送 sse
+	v1.GET("events", api.SubscribeEvents)
+	// 事件推送 websocket
+	v1.GET("events/ws", api.SubscribeEventsWs)
+	// openapi文档
+	v1.GET("openapi.json", api.GetOpenApiDoc)
+	// 下载诊断包
+	v1.GET("diagnostics/bundle", api.LocalApiTokenMid, api.DownloadDiagnosticsBundle)
 	// lcu proxy
-	v1.Any("lcu/proxy/*any", api.LcuProxy)
+	v1.Any("lcu/proxy/*any", api.LocalApiTokenMid, api.LcuProxy)
 }
@@ -0,0 +1,128 @@
+package hh_lol_prophet
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/real-web-world/hh-lol-prophet/services/db/models"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu"
+	lcuModels "github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+)
+
+const (
+	runePageNamePrefix = "先知-"
+	runePerkCount      = 9 // 6个符文+3个属性碎片
+)
+
+var (
+	errNoReplaceablePerkPage = errors.New("没有可替换的符文页")
+	allowRunePositions       = []string{lcuModels.PositionNone, lcuModels.PositionTop, lcuModels.PositionJungle,
+		lcuModels.PositionMiddle, lcuModels.PositionBottom, lcuModels.PositionUtility}
+)
+
+func checkChampionRune(item *models.ChampionRune) error {
+	if item.ChampionID <= 0 || !slices.Contains(allowRunePositions, item.Position) {
+		return errors.New("英雄或位置错误")
+	}
+	if len(item.SelectedPerkIDs) > 0 && (len(item.SelectedPerkIDs) != runePerkCount || item.PrimaryStyleID <= 0 ||
+		item.SubStyleID <= 0) {
+		return errors.New("符文配置错误")
+	}
+	if (item.Spell1ID > 0) != (item.Spell2ID > 0) || (item.Spell1ID > 0 && item.Spell1ID == item.Spell2ID) {
+		return errors.New("召唤师技能配置错误")
+	}
+	return nil
+}
+
+// 根据本地配置设置英雄的符文页及召唤师技能
+func applyChampionRune(ctx context.Context, cli lcu.Api, championID int, position string) error {
+	runeCfg, err := models.ChampionRune{}.Find(championID, position)
+	if err != nil {
+		return err
+	}
+	if runeCfg == nil {
+		return nil
+	}
+	if len(runeCfg.SelectedPerkIDs) > 0 {
+		if err = applyPerkPage(ctx, cli, runeCfg); err != nil {
+			return err
+		}
+	}
+	if runeCfg.Spell1ID > 0 && runeCfg.Spell2ID > 0 {
+		return cli.SetSummonerSpells(ctx, runeCfg.Spell1ID, runeCfg.Spell2ID)
+	}
+	return nil
+}
+
+// 优先复用先知创建的符文页,其次新建,符文页已满时替换当前可编辑的符文页
+func applyPerkPage(ctx context.Context, cli lcu.Api, runeCfg *models.ChampionRune) error {
+	page := lcuModels.PerkPage{
+		Name:            fmt.Sprintf("%s%d", runePageNamePrefix, runeCfg.ChampionID),
+		Current:         true,
+		PrimaryStyleId:  runeCfg.PrimaryStyleID,
+		SubStyleId:      runeCfg.SubStyleID,
+		SelectedPerkIds: runeCfg.SelectedPerkIDs,
+	}
+	pages, err := cli.ListPerkPages(ctx)
+	if err != nil {
+		return err
+	}
+	var reusePage, replacePage *lcuModels.PerkPage
+	customPageCount := 0
+	for i := range pages {
+		item := &pages[i]
+		if !item.IsDeletable {
+			continue
+		}
+		customPageCount++
+		if reusePage == nil && strings.HasPrefix(item.Name, runePageNamePrefix) {
+			reusePage = item
+		}
+		if replacePage == nil || item.Current {
+			replacePage = item
+		}
+	}
+	if reusePage != nil {
+		if err = cli.UpdatePerkPage(ctx, reusePage.Id, page); err != nil {
+			return err
+		}
+		return cli.SetCurrPerkPage(ctx, reusePage.Id)
+	}
+	inventory, err := cli.GetPerkInventory(ctx)
+	if err != nil {
+		return err
+	}
+	if !inventory.CanAddCustomPage || customPageCount >= inventory.OwnedPageCount {
+		if replacePage == nil {
+			return errNoReplaceablePerkPage
+		}
+		if err = cli.DelPerkPage(ctx, replacePage.Id); err != nil {
+			return err
+		}
+	}
+	_, err = cli.CreatePerkPage(ctx, page)
+	return err
+}
+
+func getLocalPlayerPosition(sessionInfo *lcuModels.ChampSelectSessionInfo) string {
+	for _, member := range sessionInfo.MyTeam {
+		if member.CellId == sessionInfo.LocalPlayerCellId {
+			return member.AssignedPosition
+		}
+	}
+	return lcuModels.PositionNone
+}
+
+// 自己当前的英雄 未选择时返回0
+func getLocalPlayerChampionID(sessionInfo *lcuModels.ChampSelectSessionInfo) int {
+	for _, member := range sessionInfo.MyTeam {
+		if member.CellId == sessionInfo.LocalPlayerCellId {
+			return member.ChampionId
+		}
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/real-web-world/hh-lol-prophet/global"
+)
+
+type (
+	// 英雄符文及召唤师技能配置 position为空时对所有位置生效
+	ChampionRune struct {
+		ID              int64           `json:"id" gorm:"primaryKey"`
+		ChampionID      int             `json:"championID" gorm:"column:champion_id"`
+		Position        string          `json:"position" gorm:"column:position"`
+		PrimaryStyleID  int             `json:"primaryStyleID" gorm:"column:primary_style_id"`
+		SubStyleID      int             `json:"subStyleID" gorm:"column:sub_style_id"`
+		SelectedPerkIDs []int           `json:"selectedPerkIDs" gorm:"column:selected_perk_ids;serializer:json"`
+		Spell1ID        int             `json:"spell1ID" gorm:"column:spell1_id"`
+		Spell2ID        int             `json:"spell2ID" gorm:"column:spell2_id"`
+		Ctx             context.Context `json:"-" gorm:"-"`
+		Tx              *gorm.DB        `json:"-" gorm:"-"` // 不为空时在该事务中执行
+	}
+)
+
+func (m ChampionRune) TableName() string {
+	return "champion_rune"
+}
+func (m ChampionRune) GetGormQuery() *gorm.DB {
+	return m.getDB().Model(m)
+}
+func (m ChampionRune) getDB() *gorm.DB {
+	db := global.SqliteDB
+	if m.Tx != nil {
+		db = m.Tx
+	}
+	if m.Ctx != nil {
+		db = db.WithContext(m.Ctx)
+	}
+	return db
+}
+
+// 优先匹配指定位置的配置 不存在时使用通用配置
+func (m ChampionRune) Find(championID int, position string) (*ChampionRune, error) {
+	list := make([]*ChampionRune, 0, 2)
+	err := m.GetGormQuery().Where("champion_id = ? and position in ?", championID, []string{position, ""}).
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	var res *ChampionRune
+	for _, item := range list {
+		if res == nil || item.Position != "" {
+			res = item
+		}
+	}
+	return res, nil
+}
+func (m ChampionRune) List() ([]*ChampionRune, error) {
+	list := make([]*ChampionRune, 0, 10)
+	err := m.GetGormQuery().Order("champion_id, position").Find(&list).Error
+	return list, err
+}
+func (m ChampionRune) Save(item *ChampionRune) error {
+	exist := &ChampionRune{}
+	err := m.GetGormQuery().Where("champion_id = ? and position = ?", item.ChampionID, item.Position).
+		Limit(1).Find(exist).Error
+	if err != nil {
+		return err
+	}
+	item.ID = exist.ID
+	return m.getDB().Save(item).Error
+}
+func (m ChampionRune) Delete(id int64) error {
+	return m.GetGormQuery().Where("id = ?", id).Delete(&ChampionRune{}).Error
+}
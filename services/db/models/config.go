@@ -14,11 +14,15 @@ type (
 		Key string          `json:"key" gorm:"column:k"`
 		Val string          `json:"val" gorm:"column:v"`
 		Ctx context.Context `json:"-" gorm:"-"`
+		Tx  *gorm.DB        `json:"-" gorm:"-"` // 不为空时在该事务中执行
 	}
 )
 
 const (
 	LocalClientConfKey = "localClient"
+	LocalApiTokenKey   = "localApiToken"
+	ActiveProfileKey   = "activeProfile"
+	ProfileKeyPrefix   = "profile."
 	InitLocalClientSql = `
 create table config
 (
@@ -40,11 +44,47 @@ func (m Config) TableName() string {
 }
 func (m Config) GetGormQuery() *gorm.DB {
 	db := global.SqliteDB
+	if m.Tx != nil {
+		db = m.Tx
+	}
 	if m.Ctx != nil {
 		db = db.WithContext(m.Ctx)
 	}
 	return db.Model(m)
 }
+
+// 不存在时返回nil
+func (m Config) Find(k string) (*Config, error) {
+	list := make([]*Config, 0, 1)
+	err := m.GetGormQuery().Where("k = ?", k).Limit(1).Find(&list).Error
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[0], nil
+}
+func (m Config) Create(k, v string) error {
+	return m.GetGormQuery().Create(&Config{Key: k, Val: v}).Error
+}
 func (m Config) Update(k, v string) error {
 	return m.GetGormQuery().Where("k = ?", k).Update("v", v).Error
 }
+
+// 不存在时创建
+func (m Config) Save(k, v string) error {
+	exist, err := m.Find(k)
+	if err != nil {
+		return err
+	}
+	if exist == nil {
+		return m.Create(k, v)
+	}
+	return m.Update(k, v)
+}
+func (m Config) Delete(k string) error {
+	return m.GetGormQuery().Where("k = ?", k).Delete(&Config{}).Error
+}
+func (m Config) ListByKeyPrefix(prefix string) ([]*Config, error) {
+	list := make([]*Config, 0)
+	err := m.GetGormQuery().Where("k like ?", prefix+"%").Order("k").Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/real-web-world/hh-lol-prophet/global"
+)
+
+type (
+	// 赛后战绩报告
+	GameReport struct {
+		ID     int64           `json:"id" gorm:"primaryKey"`
+		GameID int64           `json:"gameID" gorm:"column:game_id"`
+		Data   GameReportData  `json:"data" gorm:"column:data;serializer:json"`
+		Ctime  time.Time       `json:"ctime" gorm:"column:ctime"`
+		Ctx    context.Context `json:"-" gorm:"-"`
+	}
+	GameReportData struct {
+		GameID           int64              `json:"gameID"`
+		QueueID          int                `json:"queueID"`
+		GameMode         string             `json:"gameMode"`
+		GameDuration     int                `json:"gameDuration"` // 秒
+		GameCreation     time.Time          `json:"gameCreation"`
+		WinTeamID        int                `json:"winTeamID"`
+		PredictWinTeamID int                `json:"predictWinTeamID"` // 根据赛前得分预测的胜方 无完整预测时为0
+		Players          []GameReportPlayer `json:"players"`
+	}
+	GameReportPlayer struct {
+		SummonerID   int64    `json:"summonerID"`
+		RiotID       string   `json:"riotID"`
+		ChampionID   int      `json:"championID"`
+		TeamID       int      `json:"teamID"`
+		IsSelf       bool     `json:"isSelf"`
+		Win          bool     `json:"win"`
+		Kills        int      `json:"kills"`
+		Deaths       int      `json:"deaths"`
+		Assists      int      `json:"assists"`
+		Score        float64  `json:"score"`        // 本局得分
+		Horse        string   `json:"horse"`        // 本局得分对应的马匹
+		PredictScore *float64 `json:"predictScore"` // 赛前预测得分 未预测时为null
+		PredictHorse string   `json:"predictHorse"` // 赛前预测马匹
+		IsCarry      bool     `json:"isCarry"`      // 队伍中得分最高
+		IsFeed       bool     `json:"isFeed"`       // 队伍中得分最低
+	}
+)
+
+func (m GameReport) TableName() string {
+	return "game_report"
+}
+func (m GameReport) GetGormQuery() *gorm.DB {
+	return m.getDB().Model(m)
+}
+func (m GameReport) getDB() *gorm.DB {
+	db := global.SqliteDB
+	if m.Ctx != nil {
+		db = db.WithContext(m.Ctx)
+	}
+	return db
+}
+
+// 不存在时返回nil
+func (m GameReport) FindByGameID(gameID int64) (*GameReport, error) {
+	list := make([]*GameReport, 0, 1)
+	err := m.GetGormQuery().Where("game_id = ?", gameID).Limit(1).Find(&list).Error
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[0], nil
+}
+func (m GameReport) Save(item *GameReport) error {
+	exist, err := m.FindByGameID(item.GameID)
+	if err != nil {
+		return err
+	}
+	if exist != nil {
+		item.ID = exist.ID
+	}
+	if item.Ctime.IsZero() {
+		item.Ctime = time.Now()
+	}
+	return m.getDB().Save(item).Error
+}
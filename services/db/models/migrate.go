@@ -0,0 +1,69 @@
+package models
+
+import (
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// 按顺序执行的数据库迁移 版本号记录在sqlite user_version中
+// 只能在末尾追加 不可修改已发布的迁移
+var migrations = []string{
+	// 1: 英雄符文及召唤师技能配置
+	`
+create table if not exists champion_rune
+(
+    id                integer     not null
+        constraint champion_rune_pk
+            primary key autoincrement,
+    champion_id       integer     not null,
+    position          varchar(16) not null default '',
+    primary_style_id  integer     not null,
+    sub_style_id      integer     not null,
+    selected_perk_ids TEXT        not null,
+    spell1_id         integer     not null default 0,
+    spell2_id         integer     not null default 0
+);
+create unique index if not exists champion_rune_champion_id_position_uindex
+    on champion_rune (champion_id, position);
+`,
+	// 2: 赛后战绩报告
+	`
+create table if not exists game_report
+(
+    id      integer  not null
+        constraint game_report_pk
+            primary key autoincrement,
+    game_id integer  not null,
+    data    TEXT     not null,
+    ctime   datetime not null
+);
+create unique index if not exists game_report_game_id_uindex
+    on game_report (game_id);
+`,
+}
+
+// 当前数据库已执行的迁移版本
+func GetSchemaVersion(db *gorm.DB) (int, error) {
+	var version int
+	err := db.Raw("PRAGMA user_version").Scan(&version).Error
+	return version, err
+}
+func Migrate(db *gorm.DB) error {
+	version, err := GetSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	for i := version; i < len(migrations); i++ {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(migrations[i]).Error; err != nil {
+				return err
+			}
+			return tx.Exec("PRAGMA user_version = " + strconv.Itoa(i+1)).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
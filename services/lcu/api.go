@@ -1,6 +1,7 @@
 package lcu
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -12,10 +13,10 @@ import (
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
-	"golang.org/x/time/rate"
 
 	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
 	"github.com/real-web-world/hh-lol-prophet/services/logger"
+	"github.com/real-web-world/hh-lol-prophet/services/metrics"
 )
 
 const (
@@ -26,13 +27,9 @@ const (
 	AvailabilityOffline       models.Availability         = "offline" // 离线
 )
 
-var (
-	queryGameSummaryLimiter = rate.NewLimiter(rate.Every(time.Second/50), 50)
-)
-
 // 获取当前召唤师
-func GetCurrSummoner() (*models.CurrSummoner, error) {
-	bts, err := cli.httpGet("/lol-summoner/v1/current-summoner")
+func (cli *Client) GetCurrSummoner(ctx context.Context) (*models.CurrSummoner, error) {
+	bts, err := cli.httpGet(ctx, "/lol-summoner/v1/current-summoner")
 	if err != nil {
 		return nil, err
 	}
@@ -49,8 +46,9 @@ func GetCurrSummoner() (*models.CurrSummoner, error) {
 }
 
 // 获取比赛记录
-func ListGamesBySummonerID(summonerID int64, begin, limit int) (*models.GameListResp, error) {
-	bts, err := cli.httpGet(fmt.Sprintf("/lol-match-history/v3/matchlist/account/%d?begIndex=%d&endIndex=%d",
+func (cli *Client) ListGamesBySummonerID(ctx context.Context, summonerID int64, begin,
+	limit int) (*models.GameListResp, error) {
+	bts, err := cli.httpGet(ctx, fmt.Sprintf("/lol-match-history/v3/matchlist/account/%d?begIndex=%d&endIndex=%d",
 		summonerID, begin, begin+limit))
 	if err != nil {
 		return nil, err
@@ -65,8 +63,8 @@ func ListGamesBySummonerID(summonerID int64, begin, limit int) (*models.GameList
 }
 
 // 获取比赛记录
-func ListGamesByPUUID(puuid string, begin, limit int) (*models.GameListResp, error) {
-	bts, err := cli.httpGet(fmt.Sprintf("/lol-match-history/v1/products/lol/%s/matches?begIndex=%d&endIndex=%d",
+func (cli *Client) ListGamesByPUUID(ctx context.Context, puuid string, begin, limit int) (*models.GameListResp, error) {
+	bts, err := cli.httpGet(ctx, fmt.Sprintf("/lol-match-history/v1/products/lol/%s/matches?begIndex=%d&endIndex=%d",
 		puuid, begin, begin+limit))
 	if err != nil {
 		return nil, err
@@ -81,8 +79,8 @@ func ListGamesByPUUID(puuid string, begin, limit int) (*models.GameListResp, err
 }
 
 // 获取会话组消息记录
-func ListConversationMsg(conversationID string) ([]models.ConversationMsg, error) {
-	bts, err := cli.httpGet(fmt.Sprintf("/lol-chat/v1/conversations/%s/messages", conversationID))
+func (cli *Client) ListConversationMsg(ctx context.Context, conversationID string) ([]models.ConversationMsg, error) {
+	bts, err := cli.httpGet(ctx, fmt.Sprintf("/lol-chat/v1/conversations/%s/messages", conversationID))
 	if err != nil {
 		return nil, err
 	}
@@ -96,8 +94,8 @@ func ListConversationMsg(conversationID string) ([]models.ConversationMsg, error
 }
 
 // 获取当前对局聊天组
-func GetCurrConversationID() (string, error) {
-	bts, err := cli.httpGet("/lol-chat/v1/conversations")
+func (cli *Client) GetCurrConversationID(ctx context.Context) (string, error) {
+	bts, err := cli.httpGet(ctx, "/lol-chat/v1/conversations")
 	if err != nil {
 		return "", err
 	}
@@ -116,7 +114,7 @@ func GetCurrConversationID() (string, error) {
 }
 
 // 发送消息到聊天组
-func SendConversationMsg(msg string, conversationID string) error {
+func (cli *Client) SendConversationMsg(ctx context.Context, msg string, conversationID string) error {
 	data := struct {
 		Body string `json:"body"`
 		Type string `json:"type"`
@@ -124,34 +122,37 @@ func SendConversationMsg(msg string, conversationID string) error {
 		Body: msg,
 		Type: "chat",
 	}
-	_, err := cli.httpPost(fmt.Sprintf("/lol-chat/v1/conversations/%s/messages", conversationID), data)
-	return err
+	bts, err := cli.httpPost(ctx, fmt.Sprintf("/lol-chat/v1/conversations/%s/messages", conversationID), data)
+	if err != nil {
+		return err
+	}
+	return parseCommonResp(bts, "发送消息失败")
 }
 
 // 申请加好友
-func ApplyFriend(summonerID int64) error {
+func (cli *Client) ApplyFriend(ctx context.Context, summonerID int64) error {
 	data := struct {
 		ID string `json:"id"`
 	}{
 		ID: strconv.FormatInt(summonerID, 10),
 	}
-	_, err := cli.httpPost("/lol-chat/v1/friend-requests", data)
+	_, err := cli.httpPost(ctx, "/lol-chat/v1/friend-requests", data)
 	return err
 }
 
 // 取消加好友
-func CancelApplyFriend(summonerID int64) error {
-	_, err := cli.httpDel(fmt.Sprintf("/lol-chat/v1/friend-requests/%d", summonerID))
+func (cli *Client) CancelApplyFriend(ctx context.Context, summonerID int64) error {
+	_, err := cli.httpDel(ctx, fmt.Sprintf("/lol-chat/v1/friend-requests/%d", summonerID))
 	return err
 }
 
 // 查询用户信息
-func ListSummoner(summonerIDList []int64) ([]models.Summoner, error) {
+func (cli *Client) ListSummoner(ctx context.Context, summonerIDList []int64) ([]models.Summoner, error) {
 	idStrList := make([]string, 0, len(summonerIDList))
 	for _, id := range summonerIDList {
 		idStrList = append(idStrList, strconv.FormatInt(id, 10))
 	}
-	bts, err := cli.httpGet(fmt.Sprintf("/lol-summoner/v2/summoners?ids=[%s]",
+	bts, err := cli.httpGet(ctx, fmt.Sprintf("/lol-summoner/v2/summoners?ids=[%s]",
 		strings.Join(idStrList, ",")))
 	if len(bts) > 0 && bts[0] == '[' {
 		list := make([]models.Summoner, 0, len(summonerIDList))
@@ -172,8 +173,8 @@ func ListSummoner(summonerIDList []int64) ([]models.Summoner, error) {
 }
 
 // 查询用户信息
-func QuerySummoner(summonerID int64) (*models.Summoner, error) {
-	list, err := ListSummoner([]int64{summonerID})
+func (cli *Client) QuerySummoner(ctx context.Context, summonerID int64) (*models.Summoner, error) {
+	list, err := cli.ListSummoner(ctx, []int64{summonerID})
 	if err != nil {
 		return nil, err
 	}
@@ -184,9 +185,16 @@ func QuerySummoner(summonerID int64) (*models.Summoner, error) {
 }
 
 // 查询对局详情
-func QueryGameSummary(gameID int64) (*models.GameSummary, error) {
-	_ = queryGameSummaryLimiter.Wait(context.Background())
-	bts, err := cli.httpGet(fmt.Sprintf("/lol-match-history/v1/games/%d", gameID))
+func (cli *Client) QueryGameSummary(ctx context.Context, gameID int64) (*models.GameSummary, error) {
+	if item, ok := cli.summaryCache.get(gameID); ok {
+		metrics.IncGameSummaryCache(true)
+		return item, nil
+	}
+	metrics.IncGameSummaryCache(false)
+	waitStart := time.Now()
+	_ = cli.summaryLimiter.Wait(ctx)
+	metrics.ObserveGameSummaryLimiterWait(time.Since(waitStart))
+	bts, err := cli.httpGet(ctx, fmt.Sprintf("/lol-match-history/v1/games/%d", gameID))
 	if err != nil {
 		return nil, err
 	}
@@ -199,12 +207,16 @@ func QueryGameSummary(gameID int64) (*models.GameSummary, error) {
 	if data.CommonResp.ErrorCode != "" {
 		return nil, errors.New(fmt.Sprintf("查询对局详情失败 :%s ,gameID: %d", data.CommonResp.Message, gameID))
 	}
+	// 刚结算时的对局详情可能不完整 仅缓存已有参与者数据的对局
+	if len(data.Participants) > 0 {
+		cli.summaryCache.set(gameID, data)
+	}
 	return data, nil
 }
 
 // 查询用户信息
-func QuerySummonerByName(name string) (*models.Summoner, error) {
-	bts, err := cli.httpGet(fmt.Sprintf("/lol-summoner/v1/summoners?name=%s", url.QueryEscape(name)))
+func (cli *Client) QuerySummonerByName(ctx context.Context, name string) (*models.Summoner, error) {
+	bts, err := cli.httpGet(ctx, fmt.Sprintf("/lol-summoner/v1/summoners?name=%s", url.QueryEscape(name)))
 	if err != nil {
 		return nil, err
 	}
@@ -220,15 +232,97 @@ func QuerySummonerByName(name string) (*models.Summoner, error) {
 	return data, nil
 }
 
+// 根据riot id查询用户信息
+func (cli *Client) QuerySummonerByRiotID(ctx context.Context, gameName, tagLine string) (*models.Summoner, error) {
+	bts, err := cli.httpGet(ctx, fmt.Sprintf("/lol-summoner/v1/alias/lookup?gameName=%s&tagLine=%s",
+		url.QueryEscape(gameName), url.QueryEscape(tagLine)))
+	if err != nil {
+		return nil, err
+	}
+	data := &models.AliasLookupResp{}
+	err = json.Unmarshal(bts, data)
+	if err != nil {
+		logger.Info("搜索用户失败", zap.Error(err))
+		return nil, err
+	}
+	if data.CommonResp.ErrorCode != "" {
+		return nil, errors.New(fmt.Sprintf("搜索用户失败 :%s", data.CommonResp.Message))
+	}
+	if data.Puuid == "" {
+		return nil, errors.New("未查询到召唤师")
+	}
+	return cli.QuerySummonerByPUUID(ctx, data.Puuid)
+}
+
+// 根据puuid查询用户信息
+func (cli *Client) QuerySummonerByPUUID(ctx context.Context, puuid string) (*models.Summoner, error) {
+	bts, err := cli.httpGet(ctx, fmt.Sprintf("/lol-summoner/v2/summoners/puuid/%s", url.PathEscape(puuid)))
+	if err != nil {
+		return nil, err
+	}
+	data := &models.Summoner{}
+	err = json.Unmarshal(bts, data)
+	if err != nil {
+		logger.Info("查询用户信息失败", zap.Error(err))
+		return nil, err
+	}
+	if data.CommonResp.ErrorCode != "" {
+		return nil, errors.New(fmt.Sprintf("查询用户信息失败 :%s", data.CommonResp.Message))
+	}
+	return data, nil
+}
+
 // 接受对局
-func AcceptGame() error {
-	_, err := cli.httpPost("/lol-matchmaking/v1/ready-check/accept", nil)
+func (cli *Client) AcceptGame(ctx context.Context) error {
+	_, err := cli.httpPost(ctx, "/lol-matchmaking/v1/ready-check/accept", nil)
+	return err
+}
+
+// 拒绝对局
+func (cli *Client) DeclineGame(ctx context.Context) error {
+	_, err := cli.httpPost(ctx, "/lol-matchmaking/v1/ready-check/decline", nil)
 	return err
 }
 
+// 获取对局准备确认状态
+func (cli *Client) GetReadyCheck(ctx context.Context) (*models.ReadyCheck, error) {
+	bts, err := cli.httpGet(ctx, "/lol-matchmaking/v1/ready-check")
+	if err != nil {
+		return nil, err
+	}
+	data := &models.ReadyCheck{}
+	err = json.Unmarshal(bts, data)
+	if err != nil {
+		logger.Info("获取对局准备确认状态失败", zap.Error(err))
+		return nil, err
+	}
+	if data.CommonResp.ErrorCode != "" {
+		return nil, errors.New(fmt.Sprintf("获取对局准备确认状态失败 :%s", data.CommonResp.Message))
+	}
+	return data, nil
+}
+
+// 获取当前房间
+func (cli *Client) GetLobby(ctx context.Context) (*models.Lobby, error) {
+	bts, err := cli.httpGet(ctx, "/lol-lobby/v2/lobby")
+	if err != nil {
+		return nil, err
+	}
+	data := &models.Lobby{}
+	err = json.Unmarshal(bts, data)
+	if err != nil {
+		logger.Info("获取当前房间失败", zap.Error(err))
+		return nil, err
+	}
+	if data.CommonResp.ErrorCode != "" {
+		return nil, errors.New(fmt.Sprintf("获取当前房间失败 :%s", data.CommonResp.Message))
+	}
+	return data, nil
+}
+
 // 获取选人会话
-func GetChampSelectSession() (*models.ChampSelectSessionInfo, error) {
-	bts, err := cli.httpGet("/lol-champ-select/v1/session")
+func (cli *Client) GetChampSelectSession(ctx context.Context) (*models.ChampSelectSessionInfo, error) {
+	bts, err := cli.httpGet(ctx, "/lol-champ-select/v1/session")
 	if err != nil {
 		return nil, err
 	}
@@ -244,8 +338,8 @@ func GetChampSelectSession() (*models.ChampSelectSessionInfo, error) {
 	return data, nil
 }
 
-func ChampSelectPatchAction(championID, actionID int, patchType *models.ChampSelectPatchType,
-	completed *bool) error {
+func (cli *Client) ChampSelectPatchAction(ctx context.Context, championID, actionID int,
+	patchType *models.ChampSelectPatchType, completed *bool) error {
 	body := struct {
 		Completed  *bool                        `json:"completed,omitempty"`
 		Type       *models.ChampSelectPatchType `json:"type,omitempty"`
@@ -255,7 +349,7 @@ func ChampSelectPatchAction(championID, actionID int, patchType *models.ChampSel
 		Type:       patchType,
 		ChampionID: championID,
 	}
-	bts, err := cli.httpPatch(fmt.Sprintf("/lol-champ-select/v1/session/actions/%d", actionID), body)
+	bts, err := cli.httpPatch(ctx, fmt.Sprintf("/lol-champ-select/v1/session/actions/%d", actionID), body)
 	if err != nil {
 		return err
 	}
@@ -276,31 +370,31 @@ func ChampSelectPatchAction(championID, actionID int, patchType *models.ChampSel
 }
 
 // 预选英雄
-func PrePickChampion(championID, actionID int) error {
-	return ChampSelectPatchAction(championID, actionID, nil, nil)
+func (cli *Client) PrePickChampion(ctx context.Context, championID, actionID int) error {
+	return cli.ChampSelectPatchAction(ctx, championID, actionID, nil, nil)
 }
 
 // 选择英雄
-func PickChampion(championID, actionID int) error {
+func (cli *Client) PickChampion(ctx context.Context, championID, actionID int) error {
 	patchType := new(models.ChampSelectPatchType)
 	*patchType = ChampSelectPatchTypePick
 	completed := new(bool)
 	*completed = true
-	return ChampSelectPatchAction(championID, actionID, patchType, completed)
+	return cli.ChampSelectPatchAction(ctx, championID, actionID, patchType, completed)
 }
 
 // ban英雄
-func BanChampion(championID, actionID int) error {
+func (cli *Client) BanChampion(ctx context.Context, championID, actionID int) error {
 	patchType := new(models.ChampSelectPatchType)
 	*patchType = ChampSelectPatchTypeBan
 	completed := new(bool)
 	*completed = true
-	return ChampSelectPatchAction(championID, actionID, patchType, completed)
+	return cli.ChampSelectPatchAction(ctx, championID, actionID, patchType, completed)
 }
 
 // 查询游戏会话
-func QueryGameFlowSession() (*models.GameFlowSession, error) {
-	bts, err := cli.httpGet("/lol-gameflow/v1/session")
+func (cli *Client) QueryGameFlowSession(ctx context.Context) (*models.GameFlowSession, error) {
+	bts, err := cli.httpGet(ctx, "/lol-gameflow/v1/session")
 	if err != nil {
 		return nil, err
 	}
@@ -317,8 +411,9 @@ func QueryGameFlowSession() (*models.GameFlowSession, error) {
 }
 
 // 更新用户信息
-func UpdateSummonerProfile(updateData models.UpdateSummonerProfileData) (*models.SummonerProfileData, error) {
-	bts, err := cli.req(http.MethodPut, "/lol-chat/v1/me", updateData)
+func (cli *Client) UpdateSummonerProfile(ctx context.Context,
+	updateData models.UpdateSummonerProfileData) (*models.SummonerProfileData, error) {
+	bts, err := cli.req(ctx, http.MethodPut, "/lol-chat/v1/me", updateData)
 	if err != nil {
 		return nil, err
 	}
@@ -335,16 +430,249 @@ func UpdateSummonerProfile(updateData models.UpdateSummonerProfileData) (*models
 }
 
 // 设置离线状态
-func SetupFakerOffline() error {
+func (cli *Client) SetupFakerOffline(ctx context.Context) error {
 	data := models.UpdateSummonerProfileData{
 		Availability: AvailabilityOffline,
 	}
-	_, err := UpdateSummonerProfile(data)
+	_, err := cli.UpdateSummonerProfile(ctx, data)
 	return err
 }
 
 // 获取玩家简介信息
-func GetSummonerProfile() (*models.SummonerProfileData, error) {
+func (cli *Client) GetSummonerProfile(ctx context.Context) (*models.SummonerProfileData, error) {
 	data := models.UpdateSummonerProfileData{}
-	return UpdateSummonerProfile(data)
+	return cli.UpdateSummonerProfile(ctx, data)
+}
+
+// 获取所有符文页
+func (cli *Client) ListPerkPages(ctx context.Context) ([]models.PerkPage, error) {
+	bts, err := cli.httpGet(ctx, "/lol-perks/v1/pages")
+	if err != nil {
+		return nil, err
+	}
+	list := make([]models.PerkPage, 0, 10)
+	err = json.Unmarshal(bts, &list)
+	if err != nil {
+		logger.Info("获取符文页失败", zap.Error(err))
+		return nil, err
+	}
+	return list, nil
+}
+
+// 获取符文页库存
+func (cli *Client) GetPerkInventory(ctx context.Context) (*models.PerkInventory, error) {
+	bts, err := cli.httpGet(ctx, "/lol-perks/v1/inventory")
+	if err != nil {
+		return nil, err
+	}
+	data := &models.PerkInventory{}
+	err = json.Unmarshal(bts, data)
+	if err != nil {
+		logger.Info("获取符文页库存失败", zap.Error(err))
+		return nil, err
+	}
+	if data.CommonResp.ErrorCode != "" {
+		return nil, errors.New(fmt.Sprintf("获取符文页库存失败 :%s", data.CommonResp.Message))
+	}
+	return data, nil
+}
+
+// 创建符文页
+func (cli *Client) CreatePerkPage(ctx context.Context, page models.PerkPage) (*models.PerkPage, error) {
+	bts, err := cli.httpPost(ctx, "/lol-perks/v1/pages", page)
+	if err != nil {
+		return nil, err
+	}
+	data := &models.PerkPage{}
+	err = json.Unmarshal(bts, data)
+	if err != nil {
+		logger.Info("创建符文页失败", zap.Error(err))
+		return nil, err
+	}
+	if data.CommonResp.ErrorCode != "" {
+		return nil, errors.New(fmt.Sprintf("创建符文页失败 :%s", data.CommonResp.Message))
+	}
+	return data, nil
+}
+
+// 更新符文页
+func (cli *Client) UpdatePerkPage(ctx context.Context, pageID int, page models.PerkPage) error {
+	bts, err := cli.req(ctx, http.MethodPut, fmt.Sprintf("/lol-perks/v1/pages/%d", pageID), page)
+	if err != nil {
+		return err
+	}
+	return parseCommonResp(bts, "更新符文页失败")
+}
+
+// 删除符文页
+func (cli *Client) DelPerkPage(ctx context.Context, pageID int) error {
+	bts, err := cli.httpDel(ctx, fmt.Sprintf("/lol-perks/v1/pages/%d", pageID))
+	if err != nil {
+		return err
+	}
+	return parseCommonResp(bts, "删除符文页失败")
+}
+
+// 设置当前符文页
+func (cli *Client) SetCurrPerkPage(ctx context.Context, pageID int) error {
+	bts, err := cli.req(ctx, http.MethodPut, "/lol-perks/v1/currentpage", pageID)
+	if err != nil {
+		return err
+	}
+	return parseCommonResp(bts, "设置当前符文页失败")
+}
+
+// 设置召唤师技能
+func (cli *Client) SetSummonerSpells(ctx context.Context, spell1ID, spell2ID int) error {
+	body := struct {
+		Spell1Id int `json:"spell1Id"`
+		Spell2Id int `json:"spell2Id"`
+	}{
+		Spell1Id: spell1ID,
+		Spell2Id: spell2ID,
+	}
+	bts, err := cli.httpPatch(ctx, "/lol-champ-select/v1/session/my-selection", body)
+	if err != nil {
+		return err
+	}
+	return parseCommonResp(bts, "设置召唤师技能失败")
+}
+
+// 获取所有英雄简介
+func (cli *Client) ListChampionSummary(ctx context.Context) ([]models.ChampionSummary, error) {
+	bts, err := cli.httpGet(ctx, "/lol-game-data/assets/v1/champion-summary.json")
+	if err != nil {
+		return nil, err
+	}
+	list := make([]models.ChampionSummary, 0, 200)
+	err = json.Unmarshal(bts, &list)
+	if err != nil {
+		logger.Info("获取英雄列表失败", zap.Error(err))
+		return nil, err
+	}
+	return list, nil
+}
+
+// 大乱斗从备选席交换英雄
+func (cli *Client) SwapBenchChampion(ctx context.Context, championID int) error {
+	bts, err := cli.httpPost(ctx, fmt.Sprintf("/lol-champ-select/v1/session/bench/swap/%d", championID), nil)
+	if err != nil {
+		return err
+	}
+	return parseCommonResp(bts, "交换英雄失败")
+}
+
+// 大乱斗重随英雄
+func (cli *Client) RerollChampion(ctx context.Context) error {
+	bts, err := cli.httpPost(ctx, "/lol-champ-select/v1/session/my-selection/reroll", nil)
+	if err != nil {
+		return err
+	}
+	return parseCommonResp(bts, "重随英雄失败")
+}
+
+// 获取点赞投票
+func (cli *Client) GetHonorBallot(ctx context.Context) (*models.HonorBallot, error) {
+	bts, err := cli.httpGet(ctx, "/lol-honor-v2/v1/ballot")
+	if err != nil {
+		return nil, err
+	}
+	data := &models.HonorBallot{}
+	err = json.Unmarshal(bts, data)
+	if err != nil {
+		logger.Info("获取点赞投票失败", zap.Error(err))
+		return nil, err
+	}
+	if data.CommonResp.ErrorCode != "" {
+		return nil, errors.New(fmt.Sprintf("获取点赞投票失败 :%s", data.CommonResp.Message))
+	}
+	return data, nil
+}
+
+// 点赞玩家 honorCategory为OPT_OUT时跳过点赞
+func (cli *Client) HonorPlayer(ctx context.Context, gameID int64, honorCategory string, summonerID int64,
+	puuid string) error {
+	body := struct {
+		GameId        int64  `json:"gameId"`
+		HonorCategory string `json:"honorCategory"`
+		SummonerId    int64  `json:"summonerId"`
+		Puuid         string `json:"puuid,omitempty"`
+	}{
+		GameId:        gameID,
+		HonorCategory: honorCategory,
+		SummonerId:    summonerID,
+		Puuid:         puuid,
+	}
+	bts, err := cli.httpPost(ctx, "/lol-honor-v2/v1/honor-player", body)
+	if err != nil {
+		return err
+	}
+	return parseCommonResp(bts, "点赞失败")
+}
+
+// 获取结算数据
+func (cli *Client) GetEogStatsBlock(ctx context.Context) (*models.EogStatsBlock, error) {
+	bts, err := cli.httpGet(ctx, "/lol-end-of-game/v1/eog-stats-block")
+	if err != nil {
+		return nil, err
+	}
+	data := &models.EogStatsBlock{}
+	err = json.Unmarshal(bts, data)
+	if err != nil {
+		logger.Info("获取结算数据失败", zap.Error(err))
+		return nil, err
+	}
+	if data.CommonResp.ErrorCode != "" {
+		return nil, errors.New(fmt.Sprintf("获取结算数据失败 :%s", data.CommonResp.Message))
+	}
+	return data, nil
+}
+
+// 关闭结算界面
+func (cli *Client) DismissStats(ctx context.Context) error {
+	bts, err := cli.httpPost(ctx, "/lol-end-of-game/v1/state/dismiss-stats", nil)
+	if err != nil {
+		return err
+	}
+	return parseCommonResp(bts, "关闭结算界面失败")
+}
+
+// 再来一局 返回房间
+func (cli *Client) PlayAgain(ctx context.Context) error {
+	bts, err := cli.httpPost(ctx, "/lol-lobby/v2/play-again", nil)
+	if err != nil {
+		return err
+	}
+	return parseCommonResp(bts, "返回房间失败")
+}
+
+// 开始匹配
+func (cli *Client) StartMatchmaking(ctx context.Context) error {
+	bts, err := cli.httpPost(ctx, "/lol-lobby/v2/lobby/matchmaking/search", nil)
+	if err != nil {
+		return err
+	}
+	return parseCommonResp(bts, "开始匹配失败")
+}
+
+// 无返回体的接口 出错时会返回CommonResp 成功时可能返回数组等非对象的json
+func parseCommonResp(bts []byte, errMsg string) error {
+	bts = bytes.TrimSpace(bts)
+	if len(bts) == 0 {
+		return nil
+	}
+	if !json.Valid(bts) {
+		return errors.Errorf("%s: 响应格式错误 %s", errMsg, bts)
+	}
+	if bts[0] != '{' {
+		return nil
+	}
+	data := &models.CommonResp{}
+	if err := json.Unmarshal(bts, data); err != nil {
+		return errors.Wrap(err, errMsg)
+	}
+	if data.ErrorCode != "" {
+		return &ApiError{Msg: errMsg, Resp: *data}
+	}
+	return nil
 }
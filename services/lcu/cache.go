@@ -0,0 +1,49 @@
+package lcu
+
+import (
+	"sync"
+
+	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+)
+
+const (
+	gameSummaryCacheSize = 512
+)
+
+type (
+	// 已结束对局的详情不会再变化 按先进先出淘汰
+	gameSummaryCache struct {
+		mu    sync.Mutex
+		items map[int64]*models.GameSummary
+		keys  []int64
+		size  int
+	}
+)
+
+func newGameSummaryCache(size int) *gameSummaryCache {
+	return &gameSummaryCache{
+		items: make(map[int64]*models.GameSummary, size),
+		keys:  make([]int64, 0, size),
+		size:  size,
+	}
+}
+func (c *gameSummaryCache) get(gameID int64) (*models.GameSummary, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.items[gameID]
+	return item, ok
+}
+func (c *gameSummaryCache) set(gameID int64, item *models.GameSummary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[gameID]; ok {
+		c.items[gameID] = item
+		return
+	}
+	if len(c.keys) >= c.size {
+		delete(c.items, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+	c.items[gameID] = item
+	c.keys = append(c.keys, gameID)
+}
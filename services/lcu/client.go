@@ -2,11 +2,17 @@ package lcu
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"io"
 	"net/http"
 	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+	"github.com/real-web-world/hh-lol-prophet/services/metrics"
 )
 
 var (
@@ -19,43 +25,99 @@ var (
 		},
 		Timeout: time.Second * 30,
 	}
-
-	cli *Client
 )
 
 type (
+	// lcu接口 每个客户端实例对应一次连接 测试时可替换为假实现
+	Api interface {
+		// 召唤师
+		GetCurrSummoner(ctx context.Context) (*models.CurrSummoner, error)
+		GetSummonerProfile(ctx context.Context) (*models.SummonerProfileData, error)
+		UpdateSummonerProfile(ctx context.Context,
+			updateData models.UpdateSummonerProfileData) (*models.SummonerProfileData, error)
+		SetupFakerOffline(ctx context.Context) error
+		ListSummoner(ctx context.Context, summonerIDList []int64) ([]models.Summoner, error)
+		QuerySummoner(ctx context.Context, summonerID int64) (*models.Summoner, error)
+		QuerySummonerByName(ctx context.Context, name string) (*models.Summoner, error)
+		QuerySummonerByRiotID(ctx context.Context, gameName, tagLine string) (*models.Summoner, error)
+		QuerySummonerByPUUID(ctx context.Context, puuid string) (*models.Summoner, error)
+		// 战绩
+		ListGamesBySummonerID(ctx context.Context, summonerID int64, begin, limit int) (*models.GameListResp, error)
+		ListGamesByPUUID(ctx context.Context, puuid string, begin, limit int) (*models.GameListResp, error)
+		QueryGameSummary(ctx context.Context, gameID int64) (*models.GameSummary, error)
+		// 聊天及好友
+		ListConversationMsg(ctx context.Context, conversationID string) ([]models.ConversationMsg, error)
+		GetCurrConversationID(ctx context.Context) (string, error)
+		SendConversationMsg(ctx context.Context, msg string, conversationID string) error
+		ApplyFriend(ctx context.Context, summonerID int64) error
+		CancelApplyFriend(ctx context.Context, summonerID int64) error
+		// 房间及匹配
+		GetLobby(ctx context.Context) (*models.Lobby, error)
+		StartMatchmaking(ctx context.Context) error
+		GetReadyCheck(ctx context.Context) (*models.ReadyCheck, error)
+		AcceptGame(ctx context.Context) error
+		DeclineGame(ctx context.Context) error
+		QueryGameFlowSession(ctx context.Context) (*models.GameFlowSession, error)
+		// 英雄选择
+		GetChampSelectSession(ctx context.Context) (*models.ChampSelectSessionInfo, error)
+		ChampSelectPatchAction(ctx context.Context, championID, actionID int, patchType *models.ChampSelectPatchType,
+			completed *bool) error
+		PrePickChampion(ctx context.Context, championID, actionID int) error
+		PickChampion(ctx context.Context, championID, actionID int) error
+		BanChampion(ctx context.Context, championID, actionID int) error
+		SwapBenchChampion(ctx context.Context, championID int) error
+		RerollChampion(ctx context.Context) error
+		ListChampionSummary(ctx context.Context) ([]models.ChampionSummary, error)
+		// 符文及召唤师技能
+		ListPerkPages(ctx context.Context) ([]models.PerkPage, error)
+		GetPerkInventory(ctx context.Context) (*models.PerkInventory, error)
+		CreatePerkPage(ctx context.Context, page models.PerkPage) (*models.PerkPage, error)
+		UpdatePerkPage(ctx context.Context, pageID int, page models.PerkPage) error
+		DelPerkPage(ctx context.Context, pageID int) error
+		SetCurrPerkPage(ctx context.Context, pageID int) error
+		SetSummonerSpells(ctx context.Context, spell1ID, spell2ID int) error
+		// 结算
+		GetHonorBallot(ctx context.Context) (*models.HonorBallot, error)
+		HonorPlayer(ctx context.Context, gameID int64, honorCategory string, summonerID int64, puuid string) error
+		GetEogStatsBlock(ctx context.Context) (*models.EogStatsBlock, error)
+		DismissStats(ctx context.Context) error
+		PlayAgain(ctx context.Context) error
+	}
 	Client struct {
 		port    int
 		authPwd string
 		baseUrl string
+		// 对局详情缓存及查询限速 每个连接独立
+		summaryCache   *gameSummaryCache
+		summaryLimiter *rate.Limiter
 	}
 )
 
-func InitCli(port int, token string) {
-	cli = NewClient(port, token)
-}
+var _ Api = (*Client)(nil)
 
 func NewClient(port int, token string) *Client {
 	client := &Client{
-		port:    port,
-		authPwd: token,
+		port:           port,
+		authPwd:        token,
+		summaryCache:   newGameSummaryCache(gameSummaryCacheSize),
+		summaryLimiter: rate.NewLimiter(rate.Every(time.Second/50), 50),
 	}
 	client.baseUrl = client.fmtClientApiUrl()
 	return client
 }
-func (cli Client) httpGet(url string) ([]byte, error) {
-	return cli.req(http.MethodGet, url, nil)
+func (cli *Client) httpGet(ctx context.Context, url string) ([]byte, error) {
+	return cli.req(ctx, http.MethodGet, url, nil)
 }
-func (cli Client) httpPost(url string, body any) ([]byte, error) {
-	return cli.req(http.MethodPost, url, body)
+func (cli *Client) httpPost(ctx context.Context, url string, body any) ([]byte, error) {
+	return cli.req(ctx, http.MethodPost, url, body)
 }
-func (cli Client) httpPatch(url string, body any) ([]byte, error) {
-	return cli.req(http.MethodPatch, url, body)
+func (cli *Client) httpPatch(ctx context.Context, url string, body any) ([]byte, error) {
+	return cli.req(ctx, http.MethodPatch, url, body)
 }
-func (cli Client) httpDel(url string) ([]byte, error) {
-	return cli.req(http.MethodDelete, url, nil)
+func (cli *Client) httpDel(ctx context.Context, url string) ([]byte, error) {
+	return cli.req(ctx, http.MethodDelete, url, nil)
 }
-func (cli Client) req(method string, url string, data any) ([]byte, error) {
+func (cli *Client) req(ctx context.Context, method string, url string, data any) ([]byte, error) {
 	var body io.Reader
 	if data != nil {
 		bts, err := json.Marshal(data)
@@ -64,14 +126,25 @@ func (cli Client) req(method string, url string, data any) ([]byte, error) {
 		}
 		body = bytes.NewReader(bts)
 	}
-	req, _ := http.NewRequest(method, cli.baseUrl+url, body)
+	req, err := http.NewRequestWithContext(ctx, method, cli.baseUrl+url, body)
+	if err != nil {
+		return nil, err
+	}
 	if req.Body != nil {
 		req.Header.Add("ContentType", "application/json")
 	}
+	start := time.Now()
 	resp, err := httpCli.Do(req)
 	if err != nil {
+		metrics.ObserveLcuReq(method, url, 0, time.Since(start))
+		recentReqErrs.add(ReqErr{Time: start, Method: method, Url: url, Err: err.Error()})
 		return nil, err
 	}
+	metrics.ObserveLcuReq(method, url, resp.StatusCode, time.Since(start))
+	if resp.StatusCode >= http.StatusBadRequest {
+		recentReqErrs.add(ReqErr{Time: start, Method: method, Url: url, Status: resp.StatusCode,
+			Err: http.StatusText(resp.StatusCode)})
+	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
@@ -2,6 +2,6 @@
 
 package lcu
 
-func (cli Client) fmtClientApiUrl() string {
+func (cli *Client) fmtClientApiUrl() string {
 	return GenerateClientApiUrl(cli.port, cli.authPwd)
 }
@@ -2,6 +2,8 @@ package lcu
 
 import (
 	"fmt"
+	"net"
+	"net/http"
 	"strconv"
 
 	"github.com/pkg/errors"
@@ -20,6 +22,33 @@ var (
 	ErrLolProcessNotFound = errors.New("未找到lol进程")
 )
 
+type (
+	// lcu接口返回的错误
+	ApiError struct {
+		Msg  string
+		Resp models.CommonResp
+	}
+)
+
+func (e *ApiError) Error() string {
+	return fmt.Sprintf("%s :%s", e.Msg, e.Resp.Message)
+}
+
+// 仅连接未建立及lcu服务端错误可重试
+// 超时等无法确认请求是否已送达的错误不重试 避免重复发送
+func IsTransientErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *ApiError
+	if errors.As(err, &apiErr) {
+		status := apiErr.Resp.HttpStatus
+		return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
 func GetLolClientApiInfo() (int, string, error) {
 	return GetLolClientApiInfoAdapt()
 
@@ -0,0 +1,315 @@
+// Package lcutest 提供lcu.Api的假实现 用于在测试中驱动Prophet
+package lcutest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/real-web-world/hh-lol-prophet/services/lcu"
+	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+)
+
+var (
+	ErrNotFound = errors.New("lcutest: 未设置返回数据")
+)
+
+type (
+	// 按字段返回预设数据 未设置时返回ErrNotFound 写操作只记录调用 字段需在使用前设置
+	Fake struct {
+		mu    sync.Mutex
+		calls []Call
+		// 方法名 -> 该方法返回的错误
+		Errs map[string]error
+
+		CurrSummoner       *models.CurrSummoner
+		SummonerProfile    *models.SummonerProfileData
+		Summoners          []models.Summoner
+		GameLists          map[string]*models.GameListResp // puuid -> 战绩
+		GameSummaries      map[int64]*models.GameSummary
+		ConversationID     string
+		ConversationMsgs   []models.ConversationMsg
+		Lobby              *models.Lobby
+		ReadyCheck         *models.ReadyCheck
+		GameFlowSession    *models.GameFlowSession
+		ChampSelectSession *models.ChampSelectSessionInfo
+		ChampionSummaries  []models.ChampionSummary
+		PerkPages          []models.PerkPage
+		PerkInventory      *models.PerkInventory
+		HonorBallot        *models.HonorBallot
+		EogStatsBlock      *models.EogStatsBlock
+	}
+	Call struct {
+		Method string
+		Args   []any
+	}
+)
+
+var _ lcu.Api = (*Fake)(nil)
+
+func NewFake() *Fake {
+	return &Fake{
+		Errs:          make(map[string]error),
+		GameLists:     make(map[string]*models.GameListResp),
+		GameSummaries: make(map[int64]*models.GameSummary),
+	}
+}
+
+// 记录调用 返回为该方法设置的错误
+func (f *Fake) call(method string, args ...any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+	return f.Errs[method]
+}
+
+// 按调用顺序返回所有调用
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// 返回指定方法的调用次数
+func (f *Fake) Called(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, item := range f.calls {
+		if item.Method == method {
+			n++
+		}
+	}
+	return n
+}
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	f.calls = nil
+	f.mu.Unlock()
+}
+func getData[T any](f *Fake, method string, data *T, args ...any) (*T, error) {
+	if err := f.call(method, args...); err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+func (f *Fake) findSummoner(match func(item models.Summoner) bool) (*models.Summoner, error) {
+	for _, item := range f.Summoners {
+		if match(item) {
+			return &item, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// 召唤师
+func (f *Fake) GetCurrSummoner(context.Context) (*models.CurrSummoner, error) {
+	return getData(f, "GetCurrSummoner", f.CurrSummoner)
+}
+func (f *Fake) GetSummonerProfile(context.Context) (*models.SummonerProfileData, error) {
+	return getData(f, "GetSummonerProfile", f.SummonerProfile)
+}
+func (f *Fake) UpdateSummonerProfile(_ context.Context,
+	updateData models.UpdateSummonerProfileData) (*models.SummonerProfileData, error) {
+	return getData(f, "UpdateSummonerProfile", f.SummonerProfile, updateData)
+}
+func (f *Fake) SetupFakerOffline(context.Context) error {
+	return f.call("SetupFakerOffline")
+}
+func (f *Fake) ListSummoner(_ context.Context, summonerIDList []int64) ([]models.Summoner, error) {
+	if err := f.call("ListSummoner", summonerIDList); err != nil {
+		return nil, err
+	}
+	list := make([]models.Summoner, 0, len(summonerIDList))
+	for _, summonerID := range summonerIDList {
+		if item, err := f.findSummoner(func(item models.Summoner) bool {
+			return item.SummonerId == summonerID
+		}); err == nil {
+			list = append(list, *item)
+		}
+	}
+	return list, nil
+}
+func (f *Fake) QuerySummoner(_ context.Context, summonerID int64) (*models.Summoner, error) {
+	if err := f.call("QuerySummoner", summonerID); err != nil {
+		return nil, err
+	}
+	return f.findSummoner(func(item models.Summoner) bool {
+		return item.SummonerId == summonerID
+	})
+}
+func (f *Fake) QuerySummonerByName(_ context.Context, name string) (*models.Summoner, error) {
+	if err := f.call("QuerySummonerByName", name); err != nil {
+		return nil, err
+	}
+	return f.findSummoner(func(item models.Summoner) bool {
+		return item.DisplayName == name || item.GameName == name
+	})
+}
+func (f *Fake) QuerySummonerByRiotID(_ context.Context, gameName, tagLine string) (*models.Summoner, error) {
+	if err := f.call("QuerySummonerByRiotID", gameName, tagLine); err != nil {
+		return nil, err
+	}
+	return f.findSummoner(func(item models.Summoner) bool {
+		return item.GameName == gameName && item.TagLine == tagLine
+	})
+}
+func (f *Fake) QuerySummonerByPUUID(_ context.Context, puuid string) (*models.Summoner, error) {
+	if err := f.call("QuerySummonerByPUUID", puuid); err != nil {
+		return nil, err
+	}
+	return f.findSummoner(func(item models.Summoner) bool {
+		return item.Puuid == puuid
+	})
+}
+
+// 战绩
+func (f *Fake) ListGamesBySummonerID(_ context.Context, summonerID int64, begin,
+	limit int) (*models.GameListResp, error) {
+	if err := f.call("ListGamesBySummonerID", summonerID, begin, limit); err != nil {
+		return nil, err
+	}
+	summoner, err := f.findSummoner(func(item models.Summoner) bool {
+		return item.SummonerId == summonerID
+	})
+	if err != nil {
+		return nil, err
+	}
+	if data := f.GameLists[summoner.Puuid]; data != nil {
+		return data, nil
+	}
+	return nil, ErrNotFound
+}
+func (f *Fake) ListGamesByPUUID(_ context.Context, puuid string, begin, limit int) (*models.GameListResp, error) {
+	return getData(f, "ListGamesByPUUID", f.GameLists[puuid], puuid, begin, limit)
+}
+func (f *Fake) QueryGameSummary(_ context.Context, gameID int64) (*models.GameSummary, error) {
+	return getData(f, "QueryGameSummary", f.GameSummaries[gameID], gameID)
+}
+
+// 聊天及好友
+func (f *Fake) ListConversationMsg(_ context.Context, conversationID string) ([]models.ConversationMsg, error) {
+	if err := f.call("ListConversationMsg", conversationID); err != nil {
+		return nil, err
+	}
+	return f.ConversationMsgs, nil
+}
+func (f *Fake) GetCurrConversationID(context.Context) (string, error) {
+	if err := f.call("GetCurrConversationID"); err != nil {
+		return "", err
+	}
+	if f.ConversationID == "" {
+		return "", ErrNotFound
+	}
+	return f.ConversationID, nil
+}
+func (f *Fake) SendConversationMsg(_ context.Context, msg string, conversationID string) error {
+	return f.call("SendConversationMsg", msg, conversationID)
+}
+func (f *Fake) ApplyFriend(_ context.Context, summonerID int64) error {
+	return f.call("ApplyFriend", summonerID)
+}
+func (f *Fake) CancelApplyFriend(_ context.Context, summonerID int64) error {
+	return f.call("CancelApplyFriend", summonerID)
+}
+
+// 房间及匹配
+func (f *Fake) GetLobby(context.Context) (*models.Lobby, error) {
+	return getData(f, "GetLobby", f.Lobby)
+}
+func (f *Fake) StartMatchmaking(context.Context) error {
+	return f.call("StartMatchmaking")
+}
+func (f *Fake) GetReadyCheck(context.Context) (*models.ReadyCheck, error) {
+	return getData(f, "GetReadyCheck", f.ReadyCheck)
+}
+func (f *Fake) AcceptGame(context.Context) error {
+	return f.call("AcceptGame")
+}
+func (f *Fake) DeclineGame(context.Context) error {
+	return f.call("DeclineGame")
+}
+func (f *Fake) QueryGameFlowSession(context.Context) (*models.GameFlowSession, error) {
+	return getData(f, "QueryGameFlowSession", f.GameFlowSession)
+}
+
+// 英雄选择
+func (f *Fake) GetChampSelectSession(context.Context) (*models.ChampSelectSessionInfo, error) {
+	return getData(f, "GetChampSelectSession", f.ChampSelectSession)
+}
+func (f *Fake) ChampSelectPatchAction(_ context.Context, championID, actionID int,
+	patchType *models.ChampSelectPatchType, completed *bool) error {
+	return f.call("ChampSelectPatchAction", championID, actionID, patchType, completed)
+}
+func (f *Fake) PrePickChampion(_ context.Context, championID, actionID int) error {
+	return f.call("PrePickChampion", championID, actionID)
+}
+func (f *Fake) PickChampion(_ context.Context, championID, actionID int) error {
+	return f.call("PickChampion", championID, actionID)
+}
+func (f *Fake) BanChampion(_ context.Context, championID, actionID int) error {
+	return f.call("BanChampion", championID, actionID)
+}
+func (f *Fake) SwapBenchChampion(_ context.Context, championID int) error {
+	return f.call("SwapBenchChampion", championID)
+}
+func (f *Fake) RerollChampion(context.Context) error {
+	return f.call("RerollChampion")
+}
+func (f *Fake) ListChampionSummary(context.Context) ([]models.ChampionSummary, error) {
+	if err := f.call("ListChampionSummary"); err != nil {
+		return nil, err
+	}
+	return f.ChampionSummaries, nil
+}
+
+// 符文及召唤师技能
+func (f *Fake) ListPerkPages(context.Context) ([]models.PerkPage, error) {
+	if err := f.call("ListPerkPages"); err != nil {
+		return nil, err
+	}
+	return f.PerkPages, nil
+}
+func (f *Fake) GetPerkInventory(context.Context) (*models.PerkInventory, error) {
+	return getData(f, "GetPerkInventory", f.PerkInventory)
+}
+func (f *Fake) CreatePerkPage(_ context.Context, page models.PerkPage) (*models.PerkPage, error) {
+	if err := f.call("CreatePerkPage", page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+func (f *Fake) UpdatePerkPage(_ context.Context, pageID int, page models.PerkPage) error {
+	return f.call("UpdatePerkPage", pageID, page)
+}
+func (f *Fake) DelPerkPage(_ context.Context, pageID int) error {
+	return f.call("DelPerkPage", pageID)
+}
+func (f *Fake) SetCurrPerkPage(_ context.Context, pageID int) error {
+	return f.call("SetCurrPerkPage", pageID)
+}
+func (f *Fake) SetSummonerSpells(_ context.Context, spell1ID, spell2ID int) error {
+	return f.call("SetSummonerSpells", spell1ID, spell2ID)
+}
+
+// 结算
+func (f *Fake) GetHonorBallot(context.Context) (*models.HonorBallot, error) {
+	return getData(f, "GetHonorBallot", f.HonorBallot)
+}
+func (f *Fake) HonorPlayer(_ context.Context, gameID int64, honorCategory string, summonerID int64,
+	puuid string) error {
+	return f.call("HonorPlayer", gameID, honorCategory, summonerID, puuid)
+}
+func (f *Fake) GetEogStatsBlock(context.Context) (*models.EogStatsBlock, error) {
+	return getData(f, "GetEogStatsBlock", f.EogStatsBlock)
+}
+func (f *Fake) DismissStats(context.Context) error {
+	return f.call("DismissStats")
+}
+func (f *Fake) PlayAgain(context.Context) error {
+	return f.call("PlayAgain")
+}
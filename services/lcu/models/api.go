@@ -240,6 +240,15 @@ type (
 		Timestamp      time.Time           `json:"timestamp"`
 		Type           ConversationMsgType `json:"type"`
 	}
+	// riot id查询结果
+	AliasLookupResp struct {
+		CommonResp
+		Alias struct {
+			GameName string `json:"gameName"`
+			TagLine  string `json:"tagLine"`
+		} `json:"alias"`
+		Puuid string `json:"puuid"`
+	}
 	Summoner struct {
 		CommonResp
 		AccountId                   int64  `json:"accountId"`
@@ -481,15 +490,18 @@ type (
 		// AllowBattleBoost    bool `json:"allowBattleBoost"`
 		// AllowDuplicatePicks bool `json:"allowDuplicatePicks"`
 		// AllowLockedEvents   bool `json:"allowLockedEvents"`
-		// AllowRerolling      bool `json:"allowRerolling"`
+		AllowRerolling bool `json:"allowRerolling"`
 		// AllowSkinSelection  bool `json:"allowSkinSelection"`
 		// Bans                struct {
 		// 	MyTeamBans    []interface{} `json:"myTeamBans"`
 		// 	NumBans       int           `json:"numBans"`
 		// 	TheirTeamBans []interface{} `json:"theirTeamBans"`
 		// } `json:"bans"`
-		// BenchChampionIds   []interface{} `json:"benchChampionIds"`
-		// BenchEnabled       bool          `json:"benchEnabled"`
+		BenchChampions []struct {
+			ChampionId int  `json:"championId"`
+			IsPriority bool `json:"isPriority"`
+		} `json:"benchChampions"`
+		BenchEnabled bool `json:"benchEnabled"`
 		// BoostableSkinCount int           `json:"boostableSkinCount"`
 		// ChatDetails        struct {
 		// 	ChatRoomName     string `json:"chatRoomName"`
@@ -507,21 +519,9 @@ type (
 		// IsSpectating         bool `json:"isSpectating"`
 		LocalPlayerCellId int `json:"localPlayerCellId"`
 		// LockedEventIndex     int  `json:"lockedEventIndex"`
-		// MyTeam               []struct {
-		// 	AssignedPosition    string `json:"assignedPosition"`
-		// 	CellId              int    `json:"cellId"`
-		// 	ChampionId          int    `json:"championId"`
-		// 	ChampionPickIntent  int    `json:"championPickIntent"`
-		// 	EntitledFeatureType string `json:"entitledFeatureType"`
-		// 	SelectedSkinId      int    `json:"selectedSkinId"`
-		// 	Spell1Id            int    `json:"spell1Id"`
-		// 	Spell2Id            int    `json:"spell2Id"`
-		// 	SummonerId          int64  `json:"summonerId"`
-		// 	Team                int    `json:"team"`
-		// 	WardSkinId          int    `json:"wardSkinId"`
-		// } `json:"myTeam"`
+		MyTeam []ChampSelectTeamMember `json:"myTeam"`
 		// RecoveryCounter    int  `json:"recoveryCounter"`
-		// RerollsRemaining   int  `json:"rerollsRemaining"`
+		RerollsRemaining int `json:"rerollsRemaining"`
 		// SkipChampionSelect bool `json:"skipChampionSelect"`
 		// TheirTeam          []struct {
 		// 	AssignedPosition    string `json:"assignedPosition"`
@@ -545,6 +545,97 @@ type (
 		// } `json:"timer"`
 		// Trades []interface{} `json:"trades"`
 	}
+	ChampSelectTeamMember struct {
+		AssignedPosition    string `json:"assignedPosition"`
+		CellId              int    `json:"cellId"`
+		ChampionId          int    `json:"championId"`
+		ChampionPickIntent  int    `json:"championPickIntent"`
+		EntitledFeatureType string `json:"entitledFeatureType"`
+		SelectedSkinId      int    `json:"selectedSkinId"`
+		Spell1Id            int    `json:"spell1Id"`
+		Spell2Id            int    `json:"spell2Id"`
+		SummonerId          int64  `json:"summonerId"`
+		Team                int    `json:"team"`
+		WardSkinId          int    `json:"wardSkinId"`
+	}
+	// 符文页
+	PerkPage struct {
+		CommonResp
+		Id              int    `json:"id,omitempty"`
+		Name            string `json:"name"`
+		Current         bool   `json:"current"`
+		IsActive        bool   `json:"isActive"`
+		IsDeletable     bool   `json:"isDeletable"`
+		IsEditable      bool   `json:"isEditable"`
+		IsValid         bool   `json:"isValid"`
+		Order           int    `json:"order"`
+		PrimaryStyleId  int    `json:"primaryStyleId"`
+		SubStyleId      int    `json:"subStyleId"`
+		SelectedPerkIds []int  `json:"selectedPerkIds"`
+	}
+	// 符文页库存
+	PerkInventory struct {
+		CommonResp
+		OwnedPageCount   int  `json:"ownedPageCount"`
+		CanAddCustomPage bool `json:"canAddCustomPage"`
+	}
+	// 英雄简介 id为-1时表示未选择
+	ChampionSummary struct {
+		Id    int    `json:"id"`
+		Name  string `json:"name"`
+		Alias string `json:"alias"`
+	}
+	// 房间信息
+	Lobby struct {
+		CommonResp
+		CanStartActivity bool `json:"canStartActivity"`
+		GameConfig       struct {
+			GameMode     GameMode    `json:"gameMode"`
+			IsCustom     bool        `json:"isCustom"`
+			MaxLobbySize int         `json:"maxLobbySize"`
+			QueueId      GameQueueID `json:"queueId"`
+		} `json:"gameConfig"`
+		LocalMember struct {
+			IsLeader   bool  `json:"isLeader"`
+			SummonerId int64 `json:"summonerId"`
+		} `json:"localMember"`
+		Members []struct {
+			IsLeader   bool   `json:"isLeader"`
+			Puuid      string `json:"puuid"`
+			SummonerId int64  `json:"summonerId"`
+		} `json:"members"`
+		PartyId string `json:"partyId"`
+	}
+	// 对局准备确认
+	ReadyCheck struct {
+		CommonResp
+		PlayerResponse string  `json:"playerResponse"`
+		State          string  `json:"state"`
+		Timer          float64 `json:"timer"`
+	}
+	// 点赞投票
+	HonorBallot struct {
+		CommonResp
+		EligibleAllies []struct {
+			ChampionName string `json:"championName"`
+			GameName     string `json:"gameName"`
+			TagLine      string `json:"tagLine"`
+			Puuid        string `json:"puuid"`
+			SummonerId   int64  `json:"summonerId"`
+			SummonerName string `json:"summonerName"`
+		} `json:"eligibleAllies"`
+		GameId int64 `json:"gameId"`
+	}
+	// 结算数据
+	EogStatsBlock struct {
+		CommonResp
+		GameId    int64  `json:"gameId"`
+		QueueType string `json:"queueType"`
+		Teams     []struct {
+			IsPlayerTeam  bool `json:"isPlayerTeam"`
+			IsWinningTeam bool `json:"isWinningTeam"`
+		} `json:"teams"`
+	}
 	GameFolwSessionTeamUser struct {
 		AccountId         float64 `json:"accountId,omitempty"`
 		AdjustmentFlags   float64 `json:"adjustmentFlags,omitempty"`
@@ -599,7 +690,7 @@ type (
 		// 	Visible            bool   `json:"visible"`
 		// } `json:"gameClient"`
 		GameData struct {
-			// GameId                   int64  `json:"gameId"`
+			GameId int64 `json:"gameId"`
 			// GameName                 string `json:"gameName"`
 			// IsCustomGame             bool   `json:"isCustomGame"`
 			// Password                 string `json:"password"`
@@ -59,11 +59,20 @@ const (
 	GameStatusHostBOT        GameStatus = "hosting_BOT"               // 人机组队中-队长
 )
 const (
-	GameFlowChampionSelect GameFlow = "ChampSelect" // 英雄选择中
-	GameFlowReadyCheck     GameFlow = "ReadyCheck"  // 等待接受对局
-	GameFlowInProgress     GameFlow = "InProgress"  // 进行中
-	GameFlowMatchmaking    GameFlow = "Matchmaking" // 匹配中
-	GameFlowNone           GameFlow = "None"        // 无
+	GameFlowChampionSelect        GameFlow = "ChampSelect"           // 英雄选择中
+	GameFlowReadyCheck            GameFlow = "ReadyCheck"            // 等待接受对局
+	GameFlowInProgress            GameFlow = "InProgress"            // 进行中
+	GameFlowMatchmaking           GameFlow = "Matchmaking"           // 匹配中
+	GameFlowNone                  GameFlow = "None"                  // 无
+	GameFlowLobby                 GameFlow = "Lobby"                 // 房间中
+	GameFlowWaitingForStats       GameFlow = "WaitingForStats"       // 等待结算数据
+	GameFlowPreEndOfGame          GameFlow = "PreEndOfGame"          // 结算前 点赞阶段
+	GameFlowEndOfGame             GameFlow = "EndOfGame"             // 结算
+	GameFlowReconnect             GameFlow = "Reconnect"             // 等待重连
+	GameFlowCheckedIntoTournament GameFlow = "CheckedIntoTournament" // 已签到冠军杯赛
+	GameFlowGameStart             GameFlow = "GameStart"             // 游戏启动中
+	GameFlowFailedToLaunch        GameFlow = "FailedToLaunch"        // 游戏启动失败
+	GameFlowTerminatedInError     GameFlow = "TerminatedInError"     // 游戏异常结束
 )
 
 // 排位等级
@@ -145,3 +154,25 @@ const (
 const (
 	PlatformIdHN1 PlatformId = "HN1"
 )
+
+// 选人阶段分配位置
+const (
+	PositionNone    = ""        // 未分配
+	PositionTop     = "top"     // 上路
+	PositionJungle  = "jungle"  // 打野
+	PositionMiddle  = "middle"  // 中路
+	PositionBottom  = "bottom"  // 下路
+	PositionUtility = "utility" // 辅助
+)
+
+// 对局准备确认
+const (
+	ReadyCheckStateInProgress = "InProgress" // 等待确认中
+	ReadyCheckResponseNone    = "None"       // 未响应
+)
+
+// 点赞类型
+const (
+	HonorCategoryHeart  = "HEART"   // 点赞
+	HonorCategoryOptOut = "OPT_OUT" // 跳过
+)
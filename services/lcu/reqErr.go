@@ -0,0 +1,64 @@
+package lcu
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	recentReqErrSize = 50
+)
+
+type (
+	// lcu请求失败记录 用于诊断
+	ReqErr struct {
+		Time   time.Time `json:"time"`
+		Method string    `json:"method"`
+		Url    string    `json:"url"`
+		Status int       `json:"status"` // 请求未发出时为0
+		Err    string    `json:"err"`
+	}
+	reqErrRing struct {
+		mu    sync.Mutex
+		items []ReqErr
+		next  int
+		size  int
+	}
+)
+
+var (
+	recentReqErrs = newReqErrRing(recentReqErrSize)
+)
+
+func newReqErrRing(size int) *reqErrRing {
+	return &reqErrRing{
+		items: make([]ReqErr, 0, size),
+		size:  size,
+	}
+}
+func (r *reqErrRing) add(item ReqErr) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.items) < r.size {
+		r.items = append(r.items, item)
+		return
+	}
+	r.items[r.next] = item
+	r.next = (r.next + 1) % r.size
+}
+
+// 按时间从新到旧返回
+func (r *reqErrRing) list() []ReqErr {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := make([]ReqErr, 0, len(r.items))
+	for i := len(r.items) - 1; i >= 0; i-- {
+		res = append(res, r.items[(r.next+i)%len(r.items)])
+	}
+	return res
+}
+
+// 最近的lcu请求失败记录 按时间从新到旧
+func ListRecentReqErrs() []ReqErr {
+	return recentReqErrs.list()
+}
@@ -22,8 +22,16 @@ const (
 	OnJsonApiEventPrefixLen = len(`[8,"OnJsonApiEvent",`)
 )
 
+// ws event type
+const (
+	WsEventTypeCreate = "Create"
+	WsEventTypeUpdate = "Update"
+	WsEventTypeDelete = "Delete"
+)
+
 // WsEvt
 const (
 	WsEvtGameFlowChanged          WsEvt = "/lol-gameflow/v1/gameflow-phase" // 游戏状态切换
 	WsEvtChampSelectUpdateSession WsEvt = "/lol-champ-select/v1/session"    // 进入英雄选择阶段
+	WsEvtLobbyUpdate              WsEvt = "/lol-lobby/v2/lobby"             // 大厅变化
 )
@@ -21,7 +21,8 @@ func Error(msg string, keysAndValues ...any) {
 }
 func log(lvl zapcore.Level, msg string, keysAndValues ...any) {
 	userInfo := global.GetUserInfo()
-	if userInfo.Summoner != nil {
+	// 离线模式下日志不附带召唤师信息
+	if userInfo.Summoner != nil && !global.IsOfflineMode() {
 		summoner := userInfo.Summoner
 		keysAndValues = append(keysAndValues,
 			zap.String("buff.lol.puuid", summoner.Puuid),
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "prophet"
+)
+
+// label value
+const (
+	ResultSuccess = "success"
+	ResultFail    = "fail"
+	ResultHit     = "hit"
+	ResultMiss    = "miss"
+	StatusError   = "error" // 请求未拿到响应
+)
+
+var (
+	lcuReqTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "lcu_requests_total",
+		Help:      "lcu请求次数",
+	}, []string{"method", "route", "status"})
+	lcuReqDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "lcu_request_duration_seconds",
+		Help:      "lcu请求耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	gameSummaryCacheTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "game_summary_cache_total",
+		Help:      "对局详情缓存命中次数",
+	}, []string{"result"})
+	gameSummaryLimiterWait = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "game_summary_limiter_wait_seconds",
+		Help:      "查询对局详情限流等待时间",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+	})
+	lcuWsConnectTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "lcu_ws_connects_total",
+		Help:      "lcu websocket连接次数",
+	}, []string{"result"})
+	gameStateTransitionTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "game_state_transitions_total",
+		Help:      "游戏状态切换次数",
+	}, []string{"from", "to"})
+	playerScoreDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "player_score_duration_seconds",
+		Help:      "计算单个玩家得分耗时",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 4, 8, 16, 32},
+	})
+	chatMsgTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chat_messages_total",
+		Help:      "选人界面消息发送次数",
+	}, []string{"result"})
+
+	// 路由中的数字 uuid及聊天组id替换为占位符 避免label基数过高
+	routeIDRegex = regexp.MustCompile(`^(\d+|[0-9a-fA-F-]{32,36})$|@|%40`)
+)
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+func ObserveLcuReq(method, url string, statusCode int, duration time.Duration) {
+	route := NormalizeRoute(url)
+	status := StatusError
+	if statusCode > 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	lcuReqTotal.WithLabelValues(method, route, status).Inc()
+	lcuReqDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+func IncGameSummaryCache(hit bool) {
+	result := ResultMiss
+	if hit {
+		result = ResultHit
+	}
+	gameSummaryCacheTotal.WithLabelValues(result).Inc()
+}
+func ObserveGameSummaryLimiterWait(duration time.Duration) {
+	gameSummaryLimiterWait.Observe(duration.Seconds())
+}
+func IncLcuWsConnect(result string) {
+	lcuWsConnectTotal.WithLabelValues(result).Inc()
+}
+func IncGameStateTransition(from, to string) {
+	gameStateTransitionTotal.WithLabelValues(from, to).Inc()
+}
+func ObservePlayerScore(duration time.Duration) {
+	playerScoreDuration.Observe(duration.Seconds())
+}
+func IncChatMsg(result string) {
+	chatMsgTotal.WithLabelValues(result).Inc()
+}
+
+// 去掉query 并将路径中的id替换为{id}
+func NormalizeRoute(url string) string {
+	path, _, _ := strings.Cut(url, "?")
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if routeIDRegex.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
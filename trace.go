@@ -0,0 +1,74 @@
+package hh_lol_prophet
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/real-web-world/hh-lol-prophet/services/lcu/models"
+)
+
+const (
+	tracerName = "github.com/real-web-world/hh-lol-prophet"
+)
+
+// span attribute
+const (
+	traceAttrGameFlow       = attribute.Key("lol.gameflow")
+	traceAttrSummonerID     = attribute.Key("lol.summoner_id")
+	traceAttrSummonerCount  = attribute.Key("lol.summoner_count")
+	traceAttrGameID         = attribute.Key("lol.game_id")
+	traceAttrGameCount      = attribute.Key("lol.game_count")
+	traceAttrIsEnemy        = attribute.Key("lol.is_enemy")
+	traceAttrConversationID = attribute.Key("lol.conversation_id")
+	traceAttrChatQueueWait  = attribute.Key("chat.queue_wait_ms")
+	traceAttrChatMsgLen     = attribute.Key("chat.msg_len")
+)
+
+var (
+	// 未配置exporter时为noop tracer
+	tracer = otel.Tracer(tracerName)
+)
+
+type (
+	// 当前游戏阶段的span 该阶段内的操作都作为其子span
+	gameFlowSpan struct {
+		ctx  context.Context
+		span trace.Span
+	}
+)
+
+// 结束上一阶段的span并开始新阶段的span ctx中不应包含其他span 新span为根span
+func (p *Prophet) startGameFlowSpan(ctx context.Context, gameFlow models.GameFlow) context.Context {
+	ctx, span := tracer.Start(ctx, "gameflow."+string(gameFlow),
+		trace.WithAttributes(traceAttrGameFlow.String(string(gameFlow))))
+	p.mu.Lock()
+	prev := p.flowSpan
+	p.flowSpan = gameFlowSpan{ctx: ctx, span: span}
+	p.mu.Unlock()
+	if prev.span != nil {
+		prev.span.End()
+	}
+	return ctx
+}
+func (p *Prophet) endGameFlowSpan() {
+	p.mu.Lock()
+	prev := p.flowSpan
+	p.flowSpan = gameFlowSpan{}
+	p.mu.Unlock()
+	if prev.span != nil {
+		prev.span.End()
+	}
+}
+
+// 结束span 有错误时记录到span上
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}